@@ -0,0 +1,86 @@
+// allowlist.go
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/rs/zerolog"
+)
+
+// allowListWriter drops every top-level key not in keys or reserved, for
+// sinks with a strict mapping. A record that lost keys gets a
+// "dropped_fields" count. Key order is kept.
+type allowListWriter struct {
+	w    zerolog.LevelWriter
+	keys map[string]bool
+}
+
+func newAllowListWriter(w io.Writer, keys []string) allowListWriter {
+	allowed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		allowed[key] = true
+	}
+	return allowListWriter{w: toLevelWriter(w), keys: allowed}
+}
+
+func (a allowListWriter) Write(p []byte) (int, error) {
+	if _, err := a.w.Write(a.filter(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (a allowListWriter) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	if _, err := a.w.WriteLevel(l, a.filter(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (a allowListWriter) filter(p []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return p
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	dropped := 0
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return p
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return p
+		}
+		if !a.keys[key] && !isReservedKey(key) {
+			dropped++
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	if dropped == 0 {
+		return p
+	}
+
+	if buf.Len() > 1 {
+		buf.WriteByte(',')
+	}
+	buf.WriteString(`"dropped_fields":`)
+	buf.WriteString(strconv.Itoa(dropped))
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,40 @@
+// allowlist_test.go
+
+package logger
+
+import (
+	"testing"
+)
+
+func TestAllowedFields(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{
+		LogLevel:      "Info",
+		LogFilePath:   path,
+		AllowedFields: []string{"service", "user"},
+		IncludePod:    Bool(false),
+		IncludePID:    Bool(false),
+	})
+
+	Info("login", "user", "alice", "session_token", "abc", "client", "ios")
+	Close()
+
+	for _, record := range readRecords(t, path) {
+		if record["message"] != "login" {
+			continue
+		}
+		if record["user"] != "alice" || record["service"] != "test" {
+			t.Errorf("allowed keys missing: %v", record)
+		}
+		for _, key := range []string{"session_token", "client"} {
+			if _, ok := record[key]; ok {
+				t.Errorf("%q not stripped: %v", key, record)
+			}
+		}
+		if record["dropped_fields"] != float64(2) {
+			t.Errorf("dropped_fields = %v, want 2", record["dropped_fields"])
+		}
+		return
+	}
+	t.Fatal("record not logged")
+}
@@ -0,0 +1,163 @@
+// async.go
+
+package logger
+
+import (
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const defaultQueueSize = 1024
+
+// asyncWriter hands records to a single consumer goroutine over a FIFO
+// channel, so the caller never waits on the network and records reach the
+// wrapped writer in the order they were emitted. Writes block only when the
+// queue is full.
+type asyncWriter struct {
+	w io.Writer
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan asyncRecord
+	done   chan struct{}
+
+	// closing is closed as soon as close starts, waking writers blocked on
+	// a full queue so they let go of mu
+	closing     chan struct{}
+	closingOnce sync.Once
+
+	// abandoned makes the consumer discard what is left after closeWithin
+	// timed out
+	abandoned atomic.Bool
+}
+
+// asyncRecord is either a record to write or, when flushed is set, a marker
+// asking the consumer to report back once everything before it is written.
+type asyncRecord struct {
+	p       []byte
+	level   zerolog.Level
+	flushed chan error
+}
+
+func newAsyncWriter(w io.Writer, size int) *asyncWriter {
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	a := &asyncWriter{w: w, queue: make(chan asyncRecord, size), done: make(chan struct{}), closing: make(chan struct{})}
+	go a.run()
+	return a
+}
+
+func (a *asyncWriter) run() {
+	defer close(a.done)
+	// The level goes along so a batch behind the queue can flush on errors
+	lw := toLevelWriter(a.w)
+	for r := range a.queue {
+		if r.flushed != nil {
+			var err error
+			if f, ok := a.w.(flusher); ok {
+				err = f.Flush()
+			}
+			r.flushed <- err
+			continue
+		}
+		if a.abandoned.Load() {
+			continue
+		}
+		lw.WriteLevel(r.level, r.p)
+	}
+}
+
+func (a *asyncWriter) Write(p []byte) (int, error) {
+	return a.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (a *asyncWriter) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return 0, net.ErrClosed
+	}
+	// zerolog reuses its buffer once Write returns
+	select {
+	case a.queue <- asyncRecord{p: append([]byte(nil), p...), level: l}:
+		return len(p), nil
+	case <-a.closing:
+		return 0, net.ErrClosed
+	}
+}
+
+// Flush blocks until every record queued before the call has been written.
+func (a *asyncWriter) Flush() error {
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return nil
+	}
+	flushed := make(chan error, 1)
+	select {
+	case a.queue <- asyncRecord{flushed: flushed}:
+	case <-a.closing:
+		a.mu.RUnlock()
+		return nil
+	}
+	a.mu.RUnlock()
+
+	return <-flushed
+}
+
+// Close drains the queue and closes the wrapped writer if it is an io.Closer.
+func (a *asyncWriter) Close() error {
+	_, err := a.close(nil)
+	return err
+}
+
+// closeWithin is Close giving up on the drain after d, e.g. when the sink is
+// dead. It returns how many queued records were dropped; the wrapped writer
+// is then closed in the background.
+func (a *asyncWriter) closeWithin(d time.Duration) (int, error) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	return a.close(timer.C)
+}
+
+// release wakes writers blocked on a full queue and makes later writes fail
+// rather than wait. The queue keeps draining.
+func (a *asyncWriter) release() {
+	a.closingOnce.Do(func() { close(a.closing) })
+}
+
+func (a *asyncWriter) close(timeout <-chan time.Time) (int, error) {
+	a.release()
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return 0, nil
+	}
+	a.closed = true
+	close(a.queue)
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+	case <-timeout:
+		a.abandoned.Store(true)
+		dropped := len(a.queue)
+		go a.closeWrapped()
+		return dropped, nil
+	}
+	return 0, a.closeWrapped()
+}
+
+func (a *asyncWriter) closeWrapped() error {
+	if c, ok := a.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// async_test.go
+
+package logger
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestCloseWithTimeoutStalledSink(t *testing.T) {
+	ln := stalledListener(t)
+	initTest(t, Config{
+		LogLevel:             "Info",
+		LogAnalyserAddress:   ln.Addr().String(),
+		LogAnalyserEnabled:   true,
+		LogAnalyserAsync:     true,
+		LogAnalyserQueueSize: 4,
+		FallbackToStdout:     Bool(false),
+	})
+	async := analyserWriter.(*asyncWriter)
+
+	// Writes failing after the close are expected; keep them off stderr
+	prev := zerolog.ErrorHandler
+	zerolog.ErrorHandler = func(error) {}
+	defer func() { zerolog.ErrorHandler = prev }()
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		payload := strings.Repeat("x", 256<<10)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				Info("filler", "payload", payload)
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		<-stopped
+	}()
+
+	// Wait until the socket buffers are full and the queue stays backed up
+	deadline := time.Now().Add(10 * time.Second)
+	for full := 0; full < 30; {
+		if time.Now().After(deadline) {
+			t.Fatal("sink never stalled")
+		}
+		if len(async.queue) == cap(async.queue) {
+			full++
+		} else {
+			full = 0
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	start := time.Now()
+	err := CloseWithTimeout(500 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("CloseWithTimeout took %v", elapsed)
+	}
+	var timeout *CloseTimeoutError
+	if !errors.As(err, &timeout) || timeout.Dropped == 0 {
+		t.Fatalf("got %v, want a CloseTimeoutError with dropped records", err)
+	}
+}
+
+func TestAsyncWriterWriteAfterClose(t *testing.T) {
+	var sink recordingWriter
+	a := newAsyncWriter(&sink, 2)
+	a.Write([]byte("one\n"))
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Write([]byte("two\n")); err == nil {
+		t.Fatal("write after Close succeeded")
+	}
+	if got := sink.String(); got != "one\n" {
+		t.Fatalf("sink got %q", got)
+	}
+}
+
+func TestAsyncBatchedLogstashOrder(t *testing.T) {
+	ln, lines := lineListener(t, "tcp")
+	initTest(t, Config{
+		LogLevel:             "Info",
+		LogAnalyserAddress:   ln.Addr().String(),
+		LogAnalyserEnabled:   true,
+		LogAnalyserAsync:     true,
+		LogAnalyserQueueSize: 16,
+		LogAnalyserBatchSize: 7,
+	})
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		Info(strconv.Itoa(i))
+	}
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Anything else the logger wrote, e.g. at startup, is skipped
+	for next := 0; next < n; {
+		i, err := strconv.Atoi(receiveMessages(t, lines, 1)[0])
+		if err != nil {
+			continue
+		}
+		if i != next {
+			t.Fatalf("got line %d, want %d", i, next)
+		}
+		next++
+	}
+}
@@ -0,0 +1,54 @@
+// audit.go
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+var auditFile *reopenFile
+
+// auditLogger carries the audit record's base fields; its output is swapped
+// for a buffer on every call.
+var auditLogger zerolog.Logger
+
+var auditMu sync.Mutex
+
+var errAuditDisabled = errors.New("audit logging is not configured, set AuditFilePath")
+
+// Audit writes an audit record for action to AuditFilePath and fsyncs it
+// before returning. Audit records skip level filtering, sampling and
+// buffering, carry "audit": true, and go only to the audit file. An error
+// means the record may not be on disk.
+func Audit(action string, fields ...interface{}) error {
+	if auditFile == nil {
+		return errAuditDisabled
+	}
+
+	// The marker can't be overridden, so a caller's own "audit" is dropped
+	kept := make([]interface{}, 0, len(fields))
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i] != "audit" {
+			kept = append(kept, fields[i], fields[i+1])
+		}
+	}
+	if len(fields)%2 != 0 {
+		kept = append(kept, fields[len(fields)-1])
+	}
+
+	var buf bytes.Buffer
+	zl := auditLogger.Output(&buf)
+	event := zl.Log().Bool("audit", true).Str("action", action)
+	appendFields(event, kept).Msg(action)
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	if _, err := auditFile.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return auditFile.Sync()
+}
@@ -0,0 +1,41 @@
+// audit_test.go
+
+package logger
+
+import "testing"
+
+func TestAuditIgnoresSampling(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	auditPath := tempLogFile(t, "audit.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, AuditFilePath: auditPath, SampleRate: 1000})
+
+	for i := 0; i < 10; i++ {
+		Info("sampled")
+		if err := Audit("user.deleted", "user_id", "u1", "audit", "false"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Audit syncs before returning, so every record is on disk before Close
+	records := readRecords(t, auditPath)
+	if len(records) != 10 {
+		t.Fatalf("%d audit records, want 10", len(records))
+	}
+	for _, record := range records {
+		if record["audit"] != true || record["action"] != "user.deleted" || record["user_id"] != "u1" {
+			t.Errorf("got %v", record)
+		}
+	}
+
+	Close()
+	if n := countMessages(t, path, "sampled"); n >= 10 {
+		t.Errorf("%d of 10 Info records kept, want sampling to drop some", n)
+	}
+}
+
+func TestAuditDisabled(t *testing.T) {
+	initTest(t, Config{LogLevel: "Info", Console: true})
+	if err := Audit("user.deleted"); err != errAuditDisabled {
+		t.Errorf("got %v, want %v", err, errAuditDisabled)
+	}
+}
@@ -0,0 +1,108 @@
+// batch.go
+
+package logger
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const defaultFlushInterval = time.Second
+
+// batchWriter accumulates newline-delimited records and hands them to the
+// wrapped writer in a single Write once size records are buffered or the
+// flush interval elapses.
+type batchWriter struct {
+	w    io.Writer
+	size int
+
+	// flushOnError flushes as soon as an Error or more severe record arrives
+	flushOnError bool
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	pending int
+
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func newBatchWriter(w io.Writer, size int, interval time.Duration) *batchWriter {
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	b := &batchWriter{w: w, size: size, done: make(chan struct{})}
+	b.wg.Add(1)
+	go b.run(interval)
+	return b
+}
+
+func (b *batchWriter) run(interval time.Duration) {
+	defer b.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.Flush()
+		case <-b.done:
+			return
+		}
+	}
+}
+
+func (b *batchWriter) Write(p []byte) (int, error) {
+	return b.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (b *batchWriter) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf.Write(p)
+	b.pending++
+	if b.pending >= b.size || (b.flushOnError && l >= zerolog.ErrorLevel && l != zerolog.NoLevel) {
+		return len(p), b.flushLocked()
+	}
+	return len(p), nil
+}
+
+func (b *batchWriter) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flushLocked()
+}
+
+func (b *batchWriter) flushLocked() error {
+	if b.buf.Len() == 0 {
+		return nil
+	}
+	// The batch is dropped on failure so a dead sink can't grow it unbounded
+	_, err := b.w.Write(b.buf.Bytes())
+	b.buf.Reset()
+	b.pending = 0
+	return err
+}
+
+// Close stops the flush loop, flushes what is buffered and closes the
+// wrapped writer if it is an io.Closer. Later calls do nothing.
+func (b *batchWriter) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.done)
+		b.wg.Wait()
+
+		err = b.Flush()
+		if c, ok := b.w.(io.Closer); ok {
+			if cerr := c.Close(); err == nil {
+				err = cerr
+			}
+		}
+	})
+	return err
+}
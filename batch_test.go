@@ -0,0 +1,118 @@
+// batch_test.go
+
+package logger
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestBatchWriterSingleWrite(t *testing.T) {
+	var sink recordingWriter
+	b := newBatchWriter(&sink, 3, time.Hour)
+	defer b.Close()
+
+	for _, line := range []string{"a\n", "b\n", "c\n"} {
+		b.Write([]byte(line))
+	}
+	if sink.Writes() != 1 || sink.String() != "a\nb\nc\n" {
+		t.Fatalf("got %d writes of %q, want one write of all three lines", sink.Writes(), sink.String())
+	}
+}
+
+func TestBatchWriterFlushOnError(t *testing.T) {
+	var sink recordingWriter
+	b := newBatchWriter(&sink, 10, time.Hour)
+	b.flushOnError = true
+	defer b.Close()
+
+	b.WriteLevel(zerolog.InfoLevel, []byte("info\n"))
+	if sink.Writes() != 0 {
+		t.Fatal("info record flushed the batch")
+	}
+	b.WriteLevel(zerolog.ErrorLevel, []byte("error\n"))
+	if sink.String() != "info\nerror\n" {
+		t.Fatalf("got %q after an error record", sink.String())
+	}
+}
+
+func TestBatchedLogstashCloseTwice(t *testing.T) {
+	ln, lines := lineListener(t, "tcp")
+	initTest(t, Config{
+		LogLevel:             "Info",
+		LogAnalyserAddress:   ln.Addr().String(),
+		LogAnalyserEnabled:   true,
+		LogAnalyserBatchSize: 10,
+	})
+
+	Info("one")
+	Info("two")
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+	// A second Close, as Reinit does after the caller's own, is a no-op
+	if err := Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+
+	if got := receiveMessages(t, lines, 2); !reflect.DeepEqual(got, []string{"one", "two"}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestInitLoggerAfterClose(t *testing.T) {
+	first := tempLogFile(t, "first.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: first})
+	Info("before close")
+	Close()
+
+	second := tempLogFile(t, "second.log")
+	InitLogger(Config{ServiceName: "test", LogLevel: "Info", LogFilePath: second})
+	Info("after reinit")
+	Close()
+
+	// findRecord fails the test if InitLogger after Close was ignored
+	findRecord(t, second, "after reinit")
+}
+
+func TestBatchWriterCloseTwice(t *testing.T) {
+	var sink recordingWriter
+	b := newBatchWriter(&sink, 10, time.Hour)
+	b.Write([]byte("a\n"))
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if sink.String() != "a\n" {
+		t.Fatalf("got %q", sink.String())
+	}
+}
+
+func TestLogstashFlushOnError(t *testing.T) {
+	ln, lines := lineListener(t, "tcp")
+	initTest(t, Config{
+		LogLevel:                 "Info",
+		LogAnalyserAddress:       ln.Addr().String(),
+		LogAnalyserEnabled:       true,
+		LogAnalyserBatchSize:     100,
+		LogAnalyserFlushInterval: time.Hour,
+		FlushOnError:             true,
+	})
+
+	Info("queued")
+	select {
+	case line := <-lines:
+		t.Fatalf("got %q before any error was logged", line)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	Error("failed")
+	if got := receiveMessages(t, lines, 2); !reflect.DeepEqual(got, []string{"queued", "failed"}) {
+		t.Fatalf("got %v", got)
+	}
+}
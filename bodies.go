@@ -0,0 +1,103 @@
+// bodies.go
+
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const defaultBodyLogLimit = 4096
+
+// logBodies and bodyLogLimit are Config.LogBodies and Config.BodyLogLimit.
+var (
+	logBodies    bool
+	bodyLogLimit = defaultBodyLogLimit
+)
+
+// BodyMiddleware logs each request and response body at Debug level as
+// "request_body" and "response_body" when Config.LogBodies is set, through
+// the request logger Middleware binds. Text and JSON bodies are cut to
+// BodyLogLimit bytes; other content types log a placeholder with the type
+// and size. Without LogBodies requests pass straight through.
+func BodyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !logBodies {
+			next.ServeHTTP(w, r)
+			return
+		}
+		limit := bodyLogLimit
+
+		var reqHead []byte
+		if r.Body != nil {
+			reqHead, _ = io.ReadAll(io.LimitReader(r.Body, int64(limit)+1))
+			// The handler still reads the whole body
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(reqHead), r.Body), r.Body}
+		}
+
+		bw := &bodyWriter{ResponseWriter: w, limit: limit}
+		next.ServeHTTP(bw, r)
+
+		reqSize := r.ContentLength
+		if reqSize < 0 {
+			reqSize = int64(len(reqHead))
+		}
+		FromContext(r.Context()).Debug("http bodies",
+			"request_body", bodyField(r.Header.Get("Content-Type"), reqHead, reqSize, limit),
+			"response_body", bodyField(w.Header().Get("Content-Type"), bw.head.Bytes(), bw.size, limit))
+	})
+}
+
+// bodyWriter keeps the first limit bytes of the response and counts the rest.
+type bodyWriter struct {
+	http.ResponseWriter
+	limit int
+	head  bytes.Buffer
+	size  int64
+}
+
+func (b *bodyWriter) Write(p []byte) (int, error) {
+	if room := b.limit + 1 - b.head.Len(); room > 0 {
+		b.head.Write(p[:min(room, len(p))])
+	}
+	n, err := b.ResponseWriter.Write(p)
+	b.size += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (b *bodyWriter) Unwrap() http.ResponseWriter {
+	return b.ResponseWriter
+}
+
+func bodyField(contentType string, head []byte, size int64, limit int) string {
+	if size == 0 {
+		return ""
+	}
+	if !isTextContent(contentType) {
+		if contentType == "" {
+			contentType = "unknown content type"
+		}
+		return fmt.Sprintf("<%s, %d bytes>", contentType, size)
+	}
+	if len(head) > limit {
+		return string(head[:limit]) + "...(truncated)"
+	}
+	return string(head)
+}
+
+func isTextContent(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" ||
+		strings.HasSuffix(mediaType, "+json") || mediaType == "application/x-www-form-urlencoded"
+}
@@ -0,0 +1,55 @@
+// bodies_test.go
+
+package logger
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyMiddleware(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Debug", LogFilePath: path, LogBodies: true, BodyLogLimit: 16})
+
+	body := `{"order":"o1","items":["book","pen"]}`
+	var received string
+	handler := BodyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		received = string(b)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(make([]byte, 2048))
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	Close()
+
+	if received != body {
+		t.Errorf("handler read %q, want the whole body", received)
+	}
+	record := findRecord(t, path, "http bodies")
+	if got, want := record["request_body"], body[:16]+"...(truncated)"; got != want {
+		t.Errorf("request_body = %v, want %v", got, want)
+	}
+	if got, want := record["response_body"], "<image/png, 2048 bytes>"; got != want {
+		t.Errorf("response_body = %v, want %v", got, want)
+	}
+}
+
+func TestBodyMiddlewareDisabled(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Debug", LogFilePath: path})
+
+	handler := BodyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	Close()
+
+	if n := countMessages(t, path, "http bodies"); n != 0 {
+		t.Errorf("%d body records without LogBodies", n)
+	}
+}
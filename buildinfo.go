@@ -0,0 +1,27 @@
+// buildinfo.go
+
+package logger
+
+import (
+	"runtime/debug"
+
+	"github.com/rs/zerolog"
+)
+
+// withBuildInfo adds the VCS revision and commit time embedded by the Go
+// toolchain. Binaries built without VCS stamping (e.g. go run) get neither.
+func withBuildInfo(ctx zerolog.Context) zerolog.Context {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ctx
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			ctx = ctx.Str("vcs_revision", setting.Value)
+		case "vcs.time":
+			ctx = ctx.Str("vcs_time", setting.Value)
+		}
+	}
+	return ctx
+}
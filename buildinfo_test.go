@@ -0,0 +1,38 @@
+// buildinfo_test.go
+
+package logger
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestIncludeBuildInfo(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, IncludeBuildInfo: true})
+	Info("started")
+	Close()
+	record := findRecord(t, path, "started")
+
+	// Test binaries usually carry no VCS stamp; either way the fields must
+	// match the build info
+	want := map[string]string{}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				want["vcs_revision"] = setting.Value
+			case "vcs.time":
+				want["vcs_time"] = setting.Value
+			}
+		}
+	}
+	for _, key := range []string{"vcs_revision", "vcs_time"} {
+		got, present := record[key]
+		if value, ok := want[key]; ok && got != value {
+			t.Errorf("%s = %v, want %q", key, got, value)
+		} else if !ok && present {
+			t.Errorf("%s = %v without build info", key, got)
+		}
+	}
+}
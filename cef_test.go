@@ -0,0 +1,59 @@
+// cef_test.go
+
+package logger
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCEFWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := CEFWriter{W: &buf, Vendor: "Praction", Product: "billing", Version: "1.4"}
+	record := `{"level":"error","time":"2024-06-01T10:00:00Z","message":"login failed | locked","src":"10.0.0.7","suser":"a=b","attempts":3}`
+	if _, err := w.Write([]byte(record)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `CEF:0|Praction|billing|1.4|error|login failed \| locked|7|` +
+		`rt=1717236000000 msg=login failed | locked src=10.0.0.7 suser=a\=b attempts=3` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestCEFOutput(t *testing.T) {
+	path := tempLogFile(t, "siem.cef")
+	initTest(t, Config{
+		ServiceName:  "billing",
+		LogLevel:     "Info",
+		CEFVendor:    "Praction",
+		CEFVersion:   "1.4",
+		ExtraOutputs: []OutputSpec{{Path: path, Format: "cef", Level: "warn"}},
+	})
+	Warn("disk nearly full", "volume", "/data")
+	Close()
+
+	out, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line := string(out); !strings.HasPrefix(line, "CEF:0|Praction|billing|1.4|warn|disk nearly full|5|") || !strings.Contains(line, " volume=/data") {
+		t.Errorf("got %q", line)
+	}
+}
+
+func TestCEFWriterNonObject(t *testing.T) {
+	for _, input := range []string{"[1,2]\n", `"text"`, "42"} {
+		var buf bytes.Buffer
+		w := CEFWriter{W: &buf, Vendor: "Praction", Product: "billing", Version: "1.4"}
+		if _, err := w.Write([]byte(input)); err != errNotObject {
+			t.Errorf("%q: got %v, want %v", input, err, errNotObject)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%q: wrote %q", input, buf.String())
+		}
+	}
+}
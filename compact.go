@@ -0,0 +1,52 @@
+// compact.go
+
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// compactWriter rewrites each record as "<time> <L> <message>", with L the
+// level's first letter, for targets with very small log buffers.
+type compactWriter struct {
+	w zerolog.LevelWriter
+}
+
+func newCompactWriter(w io.Writer) compactWriter {
+	return compactWriter{w: toLevelWriter(w)}
+}
+
+func (c compactWriter) Write(p []byte) (int, error) {
+	if _, err := c.w.Write(compactLine(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c compactWriter) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	if _, err := c.w.WriteLevel(l, compactLine(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func compactLine(p []byte) []byte {
+	var record struct {
+		Time    string `json:"time"`
+		Level   string `json:"level"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(p, &record); err != nil {
+		return p
+	}
+
+	letter := "-"
+	if record.Level != "" {
+		letter = strings.ToUpper(record.Level[:1])
+	}
+	return []byte(record.Time + " " + letter + " " + newlineEscaper.Replace(record.Message) + "\n")
+}
@@ -0,0 +1,80 @@
+// compact_test.go
+
+package logger
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	jsonPath := tempLogFile(t, "app.json")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: jsonPath})
+	Warn("battery low", "cell", "2")
+	Close()
+	full, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compactPath := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: compactPath, Compact: true})
+	Warn("battery low", "cell", "2")
+	Close()
+	out, err := os.ReadFile(compactPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := strings.SplitN(string(out), "\n", 2)[0]
+	fullLine := strings.SplitN(string(full), "\n", 2)[0]
+	if !strings.HasSuffix(line, " W battery low") {
+		t.Errorf("compact line %q, want the level letter and message", line)
+	}
+	if len(line)*3 > len(fullLine) {
+		t.Errorf("compact line is %d bytes against %d for JSON", len(line), len(fullLine))
+	}
+}
+
+func TestCompactKeepsOtherFormats(t *testing.T) {
+	stderr := swapPipe(t, &os.Stderr)
+	path := tempLogFile(t, "app.log")
+	jsonPath := tempLogFile(t, "extra.json")
+	logfmtPath := tempLogFile(t, "app.logfmt")
+	cefPath := tempLogFile(t, "siem.cef")
+	initTest(t, Config{
+		LogLevel:    "Info",
+		LogFilePath: path,
+		Compact:     true,
+		ExtraOutputs: []OutputSpec{
+			{Path: jsonPath},
+			{Path: logfmtPath, Format: "logfmt"},
+			{Path: cefPath, Format: "cef"},
+		},
+	})
+	Warn("battery low", "cell", "2")
+	Close()
+
+	for _, p := range []string{path, jsonPath} {
+		out, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line := strings.SplitN(string(out), "\n", 2)[0]; !strings.HasSuffix(line, " W battery low") {
+			t.Errorf("%s: got %q, want a compact line", p, line)
+		}
+	}
+	for p, want := range map[string]string{logfmtPath: `message="battery low"`, cefPath: "|battery low|"} {
+		out, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(out), want) || !strings.Contains(string(out), "cell") {
+			t.Errorf("%s: got %q, want %q and the cell field", p, out, want)
+		}
+	}
+	if out := stderr(); strings.Contains(out, "panic") {
+		t.Errorf("stderr = %q", out)
+	}
+}
@@ -4,28 +4,128 @@ package logger
 
 import (
 	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
 )
 
 type Config struct {
-	ServiceName        string
-	PodName            string
-	LogLevel           string // Log level as string (e.g., "Debug", "Info", etc.)
-	LogAnalyserAddress string // Optional, set to nil if not used
-	LogAnalyserEnabled bool   // Optional, set to true if not used
-	Console            bool   // Optional, set to false if not used
-	LogFilePath        string // Optional, leave empty if not used
+	ServiceName                 string
+	PodName                     string
+	LogLevel                    string              // Log level as string (e.g., "Debug", "Info", etc.)
+	LogAnalyserAddress          string              // Optional, set to nil if not used
+	LogAnalyserNetwork          string              // Optional, "tcp" (default), "udp" or "unix"; for "unix" the address is a socket path
+	LogAnalyserEnabled          bool                // Optional, set to true if not used
+	LogAnalyserBatchSize        int                 // Optional, records per Logstash write; 0 or 1 disables batching, ignored over udp
+	LogAnalyserFlushInterval    time.Duration       // Optional, max time a batch is held, defaults to 1s
+	FlushOnError                bool                // Optional, sends the Logstash batch as soon as an Error or more severe record is added
+	LogAnalyserAsync            bool                // Optional, send to Logstash from a background goroutine, preserving order
+	LogAnalyserQueueSize        int                 // Optional, records queued for the async sender, defaults to 1024
+	Console                     bool                // Optional, set to false if not used
+	ConsoleStream               string              // Optional, "stdout" (default) or "stderr"
+	ConsoleFieldOrder           []string            // Optional, console fields shown first, in this order; others follow alphabetically
+	ConsoleLocalTime            bool                // Optional, console shows local time; JSON outputs always use UTC
+	LogFilePath                 string              // Optional, leave empty if not used; may contain date tokens such as %Y-%m-%d
+	FileBufferSize              int                 // Optional, bytes buffered before writing to LogFilePath, flushed at least every second; 0 writes each record
+	ErrorFilePath               string              // Optional, additionally writes Error and above to this file
+	AuditFilePath               string              // Optional, file receiving Audit records, each fsynced before Audit returns
+	DurationUnit                string              // Optional, unit for time.Duration fields: "ms" (default), "s" or "ns"
+	TimestampPrecision          string              // Optional, fractional digits of "time": "seconds" (default), "millis", "micros" or "nanos"
+	SampleRate                  uint32              // Optional, keep 1 of every SampleRate records; 0 or 1 disables sampling
+	SampleBurst                 uint32              // Optional, records per level always kept before SampleRate applies
+	SampleBurstPeriod           time.Duration       // Optional, renews SampleBurst every period; 0 grants it once
+	LevelWarmup                 time.Duration       // Optional, after SetLevel lowers the level, newly enabled records ramp from 1 in LevelWarmupRate to all over this window
+	LevelWarmupRate             uint32              // Optional, sampling at the start of LevelWarmup, defaults to 1 in 10
+	CallerFuncName              bool                // Optional, adds a "func" field with the calling function's name
+	IncludeBuildInfo            bool                // Optional, adds "vcs_revision" and "vcs_time" from the embedded build info
+	IncludeResourceLimits       bool                // Optional, adds "mem_limit_bytes" and "cpu_limit" from the container's cgroup, read once at init
+	ValidateSchema              bool                // Optional, development aid warning on stderr when a record lacks a required key
+	SchemaRequiredKeys          []string            // Optional, keys checked by ValidateSchema, defaults to service, level and time
+	SampleByField               string              // Optional, field whose values are each rate limited independently (e.g. "path")
+	SampleByFieldLimit          uint32              // Optional, records kept per distinct SampleByField value per period
+	SampleByFieldPeriod         time.Duration       // Optional, period for SampleByFieldLimit, defaults to 1s
+	RequiredFields              map[string][]string // Optional, keys every record at a level (e.g. "error") must carry, flagged as "missing_required_field"
+	IncludeGoroutineID          bool                // Optional, adds a "goid" field; costs a runtime.Stack call per record
+	LogAnalyserBreakerThreshold int                 // Optional, consecutive Logstash failures that open the circuit; 0 disables it
+	LogAnalyserBreakerCooldown  time.Duration       // Optional, how long the open circuit skips Logstash, defaults to 10s
+	ExtraOutputs                []OutputSpec        // Optional, additional files each with their own format and level
+	TraceSampleRate             float64             // Optional, fraction of trace_id values whose records are all kept; 0 or 1 disables it
+	TraceSampleSeed             uint64              // Optional, hash seed so services agree on which traces are kept
+	LogAnalyserDeadLetterPath   string              // Optional, file receiving Logstash records that still fail after retries
+	LogAnalyserRetries          int                 // Optional, extra Logstash send attempts before dead-lettering a record
+	OTLPEndpoint                string              // Optional, OpenTelemetry collector address; requires the otlp build tag
+	OTLPProtocol                string              // Optional, "grpc" (default) or "http"
+	OTLPInsecure                bool                // Optional, disable TLS for the OTLP connection
+	ProtoIngestAddress          string              // Optional, gRPC ingestion service receiving protobuf records; requires the proto build tag and SetProtoStream
+	NormalizeKeys               bool                // Optional, convert field keys to snake_case, e.g. "userID" to "user_id"
+	IncludeSequence             bool                // Optional, adds a "seq" field incremented for every record, starting at 1
+	IncludeUptime               bool                // Optional, adds "uptime_ms", the milliseconds since InitLogger
+	Masker                      MaskFunc            // Optional, rewrites string field values; ok=true replaces the value
+	HashFields                  []string            // Optional, string fields logged as the hex SHA-256 of HashSalt and the value, after Masker
+	HashSalt                    string              // Optional, prefixed to values before hashing
+	FieldTypes                  map[string]string   // Optional, converts string values of these keys to "int", "float" or "bool"
+	LogBodies                   bool                // Optional, BodyMiddleware logs HTTP request and response bodies at Debug; for debug builds
+	BodyLogLimit                int                 // Optional, bytes of a text or JSON body logged, defaults to 4096
+	ByteEncoding                string              // Optional, encoding for []byte fields: "hex" (default) or "base64"
+	IncludeService              *bool               // Optional, adds the "service" field, defaults to true
+	IncludePod                  *bool               // Optional, adds the "pod" field, defaults to true
+	IncludePID                  *bool               // Optional, adds the "pid" field, defaults to true
+	FallbackToStdout            *bool               // Optional, writes a record to stdout when its file or Logstash write fails, defaults to true
+	FallbackStdout              bool                // Optional, writes to stdout when no output is configured; otherwise InitLogger fails
+	HTTPPushURL                 string              // Optional, endpoint receiving batched records over HTTP POST
+	HTTPPayloadEncoder          PayloadEncoder      // Optional, request body format, defaults to a Loki stream labelled with the service
+	HTTPPushBatchSize           int                 // Optional, records per request, defaults to 100
+	HTTPPushFlushInterval       time.Duration       // Optional, max time a batch is held, defaults to 1s
+	Compact                     bool                // Optional, minimal "<time> <L> <message>" lines in the log files and JSON ExtraOutputs; other outputs keep their format
+	PrettyJSON                  bool                // Optional, indents records written to LogFilePath; network outputs stay compact
+	CaptureStacks               *bool               // Optional, attach stack traces in the *WithError helpers, defaults to true
+	RecoverFromLogPanics        *bool               // Optional, a panic while building or writing a record is reported on stderr instead of propagating, defaults to true
+	Hooks                       []zerolog.Hook      // Optional, run on every emitted record, e.g. a PrometheusHook
+	Journald                    bool                // Optional, sends records to the local journald over its native protocol; Linux only
+	OnConnectionStateChange     ConnectionStateFunc // Optional, called when the Logstash connection drops or recovers
+	UDPAddress                  string              // Optional, host:port receiving each record as a UDP datagram
+	UDPMaxDatagramSize          int                 // Optional, largest datagram sent before a record is chunked, defaults to 1420
+	UDPChunker                  Chunker             // Optional, how oversized records are split, defaults to GELFChunker
+	AllowedFields               []string            // Optional, when set only these keys and the reserved ones (time, level, message, ...) are emitted
+	RingBufferSize              int                 // Optional, keeps this many recent records in memory for RecentLogs
+	CEFVendor                   string              // Optional, Device Vendor in the header of "cef" ExtraOutputs
+	CEFProduct                  string              // Optional, Device Product in the CEF header, defaults to ServiceName
+	CEFVersion                  string              // Optional, Device Version in the CEF header
+	Environment                 string              // Optional, adds an "env" field; one of dev, staging or prod
+	AllowAnyEnvironment         bool                // Optional, accept any non-empty Environment value
 }
 
-func NewLogger(serviceName string, console bool, pod string, logFilePath string, logAnalyserAddress string, logLevel string, LogAnalyserEnabled bool) (Config, error) {
-	if !console && logFilePath == "" && logAnalyserAddress == "" {
-		return Config{}, errors.New("at least one logging option (Console, LogFile, LogAnalyserAddress) must be selected")
-	}
+// Bool returns a pointer to v, for the optional *bool Config fields.
+func Bool(v bool) *bool {
+	return &v
+}
 
-	if serviceName == "" && pod == "" {
-		return Config{}, errors.New("service name and PodName must be provided")
-	}
+// isEnabled reads an optional *bool Config field that defaults to true.
+func isEnabled(b *bool) bool {
+	return b == nil || *b
+}
 
-	return Config{
+// MaskFunc rewrites a string field value; returning ok=true replaces it.
+type MaskFunc func(key, value string) (masked string, ok bool)
+
+// ConnectionStateFunc is told when the Logstash connection drops (false) or
+// recovers (true).
+type ConnectionStateFunc func(connected bool)
+
+// OutputSpec describes an additional log file output.
+type OutputSpec struct {
+	Path   string
+	Format string // "json" (default), "pretty", "logfmt", "cef" or "console"
+	Level  string // Minimum level written, defaults to every level
+}
+
+func NewLogger(serviceName string, console bool, pod string, logFilePath string, logAnalyserAddress string, logLevel string, LogAnalyserEnabled bool) (Config, error) {
+	config := Config{
 		ServiceName:        serviceName,
 		PodName:            pod,
 		LogLevel:           logLevel,
@@ -33,5 +133,132 @@ func NewLogger(serviceName string, console bool, pod string, logFilePath string,
 		LogAnalyserEnabled: LogAnalyserEnabled,
 		Console:            console,
 		LogFilePath:        logFilePath,
-	}, nil
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// Validate reports the first problem that would stop the config from
+// producing a working logger. InitLogger runs it too, so Config literals get
+// the same checks as NewLogger.
+func (c Config) Validate() error {
+	// A LogAnalyserAddress only counts once LogAnalyserEnabled is set
+	logstash := c.LogAnalyserEnabled && c.LogAnalyserAddress != ""
+	if !c.Console && c.LogFilePath == "" && !logstash && len(c.ExtraOutputs) == 0 && c.OTLPEndpoint == "" && c.ProtoIngestAddress == "" && c.HTTPPushURL == "" && !c.Journald && c.UDPAddress == "" && !c.FallbackStdout {
+		return errors.New("at least one logging option (Console, LogFile, LogAnalyserAddress) must be selected, or FallbackStdout set")
+	}
+
+	if c.ServiceName == "" && c.PodName == "" {
+		return errors.New("service name and PodName must be provided")
+	}
+
+	if c.LogLevel != "" {
+		if _, ok := lookupLogLevel(c.LogLevel); !ok {
+			return fmt.Errorf("unknown log level %q", c.LogLevel)
+		}
+	}
+
+	if c.LogAnalyserEnabled && c.LogAnalyserAddress == "" {
+		return errors.New("LogAnalyserAddress must be set when LogAnalyserEnabled is true")
+	}
+	switch c.LogAnalyserNetwork {
+	case "", "tcp", "udp", "unix":
+	default:
+		return fmt.Errorf("unknown log analyser network %q, want tcp, udp or unix", c.LogAnalyserNetwork)
+	}
+	if c.LogAnalyserAddress != "" && c.LogAnalyserNetwork != "unix" {
+		if err := validateAddress(c.LogAnalyserAddress); err != nil {
+			return fmt.Errorf("invalid log analyser address: %w", err)
+		}
+	}
+	if c.UDPAddress != "" {
+		if err := validateAddress(c.UDPAddress); err != nil {
+			return fmt.Errorf("invalid UDP address: %w", err)
+		}
+	}
+
+	for level := range c.RequiredFields {
+		if _, ok := lookupLogLevel(level); !ok {
+			return fmt.Errorf("RequiredFields has unknown log level %q", level)
+		}
+	}
+
+	for key, typ := range c.FieldTypes {
+		switch typ {
+		case "int", "float", "bool":
+		default:
+			return fmt.Errorf("field %q has unknown type %q, want int, float or bool", key, typ)
+		}
+	}
+
+	// Only LogFilePath has its date tokens expanded when it is opened
+	for _, path := range []string{expandPath(c.LogFilePath, time.Now()), c.ErrorFilePath, c.LogAnalyserDeadLetterPath, c.AuditFilePath} {
+		if path == "" {
+			continue
+		}
+		if err := validateFilePath(path); err != nil {
+			return err
+		}
+	}
+	for i, spec := range c.ExtraOutputs {
+		switch strings.ToLower(spec.Format) {
+		case "", "json", "pretty", "logfmt", "cef", "console":
+		default:
+			return fmt.Errorf("ExtraOutputs[%d].Format has unknown format %q, want json, pretty, logfmt, cef or console", i, spec.Format)
+		}
+		if spec.Level != "" {
+			if _, ok := lookupLogLevel(spec.Level); !ok {
+				return fmt.Errorf("ExtraOutputs[%d].Level has unknown log level %q", i, spec.Level)
+			}
+		}
+		if err := validateFilePath(spec.Path); err != nil {
+			return err
+		}
+	}
+
+	return validateEnvironment(c.Environment, c.AllowAnyEnvironment)
+}
+
+// validateAddress checks a host:port address, calling out the common mistake
+// of an unbracketed IPv6 literal such as "::1:5000".
+func validateAddress(address string) error {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		if strings.Count(address, ":") > 1 && !strings.HasPrefix(address, "[") {
+			return fmt.Errorf("IPv6 address %q must be bracketed, e.g. \"[::1]:5000\"", address)
+		}
+		return fmt.Errorf("%q is not host:port: %w", address, err)
+	}
+	if port == "" {
+		return fmt.Errorf("%q has no port", address)
+	}
+	return nil
+}
+
+func validateFilePath(path string) error {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return fmt.Errorf("log file path %q is a directory", path)
+	}
+	dir := filepath.Dir(path)
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("log file directory %q is not accessible: %w", dir, err)
+	}
+	return nil
+}
+
+var environments = []string{"dev", "staging", "prod"}
+
+func validateEnvironment(env string, allowAny bool) error {
+	if env == "" || allowAny {
+		return nil
+	}
+	for _, e := range environments {
+		if env == e {
+			return nil
+		}
+	}
+	return errors.New("environment must be one of dev, staging, prod")
 }
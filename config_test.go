@@ -0,0 +1,92 @@
+// config_test.go
+
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateLogFileDirectory(t *testing.T) {
+	dir := t.TempDir()
+	err := Config{ServiceName: "test", LogFilePath: dir}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "is a directory") || !strings.Contains(err.Error(), dir) {
+		t.Errorf("got %v, want an error naming the directory", err)
+	}
+
+	err = Config{ServiceName: "test", LogFilePath: dir + "/missing/app.log"}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "not accessible") {
+		t.Errorf("got %v, want an error for the missing directory", err)
+	}
+}
+
+func TestValidateLogAnalyserNetwork(t *testing.T) {
+	for network, ok := range map[string]bool{"": true, "tcp": true, "udp": true, "unix": true, "sctp": false} {
+		config := Config{ServiceName: "test", LogAnalyserAddress: "localhost:5000", LogAnalyserEnabled: true, LogAnalyserNetwork: network}
+		if err := config.Validate(); (err == nil) != ok {
+			t.Errorf("network %q: got %v", network, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for i, tt := range []struct {
+		config Config
+		want   string
+	}{
+		{Config{ServiceName: "test"}, "at least one logging option"},
+		{Config{Console: true}, "service name"},
+		{Config{ServiceName: "test", Console: true, LogLevel: "verbose"}, `unknown log level "verbose"`},
+		{Config{ServiceName: "test", Console: true, LogAnalyserEnabled: true}, "LogAnalyserAddress must be set"},
+		{Config{ServiceName: "test", LogAnalyserEnabled: true, LogAnalyserAddress: "logstash"}, "invalid log analyser address"},
+		{Config{ServiceName: "test", Console: true, FieldTypes: map[string]string{"status": "number"}}, `unknown type "number"`},
+		{Config{ServiceName: "test", ExtraOutputs: []OutputSpec{{Path: "app.log", Level: "verbose"}}}, `ExtraOutputs[0].Level has unknown log level "verbose"`},
+		{Config{ServiceName: "test", ExtraOutputs: []OutputSpec{{Path: "a.log"}, {Path: "b.log", Format: "xml"}}}, `ExtraOutputs[1].Format has unknown format "xml"`},
+	} {
+		err := tt.config.Validate()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("config %d: got %v, want an error containing %q", i, err, tt.want)
+		}
+	}
+
+	valid := Config{ServiceName: "test", Console: true, LogLevel: "Debug", ExtraOutputs: []OutputSpec{{Path: "app.log", Format: "Logfmt", Level: "notice"}}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+}
+
+func TestFallbackStdout(t *testing.T) {
+	err := Config{ServiceName: "test"}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "FallbackStdout") {
+		t.Errorf("got %v, want an error pointing at FallbackStdout", err)
+	}
+
+	stdout := swapPipe(t, &os.Stdout)
+	initTest(t, Config{LogLevel: "Info", FallbackStdout: true})
+	Info("to stdout")
+	Close()
+	if out := stdout(); !strings.Contains(out, `"message":"to stdout"`) {
+		t.Errorf("stdout = %q", out)
+	}
+}
+
+func TestValidateExpandsOnlyLogFilePath(t *testing.T) {
+	dir := t.TempDir()
+	// ErrorFilePath is opened as written, LogFilePath with its tokens expanded
+	for _, sub := range []string{"literal-%Y", "dated-" + time.Now().Format("2006")} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := Config{
+		ServiceName:   "test",
+		LogFilePath:   filepath.Join(dir, "dated-%Y", "app.log"),
+		ErrorFilePath: filepath.Join(dir, "literal-%Y", "err.log"),
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("got %v, want the paths InitLogger opens to pass", err)
+	}
+}
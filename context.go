@@ -0,0 +1,42 @@
+// context.go
+
+package logger
+
+import (
+	"context"
+	"time"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying l, so downstream code can log with
+// the fields l was bound with.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger stored by NewContext, or a logger writing
+// through the package logger when ctx carries none.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok && l != nil {
+		return l
+	}
+	return &Logger{}
+}
+
+// WithDeadline returns a Logger adding "deadline_ms", the milliseconds left
+// until ctx's deadline at the time each record is logged. Without a deadline
+// it adds nothing.
+func WithDeadline(ctx context.Context) *Logger {
+	return (&Logger{}).WithDeadline(ctx)
+}
+
+func (l *Logger) WithDeadline(ctx context.Context) *Logger {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return l
+	}
+	return l.With("deadline_ms", func() interface{} {
+		return anyValue{time.Until(deadline).Milliseconds()}
+	})
+}
@@ -0,0 +1,48 @@
+// context_test.go
+
+package logger
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFromContext(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	l := With("request_id", "r1")
+	ctx := NewContext(context.Background(), l)
+	if got := FromContext(ctx); got != l {
+		t.Errorf("FromContext = %p, want %p", got, l)
+	}
+	FromContext(ctx).Info("bound")
+	FromContext(context.Background()).Info("fallback")
+	Close()
+
+	if got := findRecord(t, path, "bound")["request_id"]; got != "r1" {
+		t.Errorf("request_id = %v", got)
+	}
+	if record := findRecord(t, path, "fallback"); record["request_id"] != nil {
+		t.Errorf("fallback logger carries %v", record["request_id"])
+	}
+}
+
+func TestWithDeadline(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	WithDeadline(ctx).Info("with deadline")
+	WithDeadline(context.Background()).Info("without deadline")
+	Close()
+
+	if ms, ok := findRecord(t, path, "with deadline")["deadline_ms"].(float64); !ok || ms <= 0 || ms > 1000 {
+		t.Errorf("deadline_ms = %v, want between 0 and 1000", ms)
+	}
+	if ms, ok := findRecord(t, path, "without deadline")["deadline_ms"]; ok {
+		t.Errorf("deadline_ms = %v without a deadline", ms)
+	}
+}
@@ -0,0 +1,116 @@
+// deadletter.go
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+)
+
+var deadLetter *deadLetterWriter
+
+// deadLetterWriter retries failed Logstash writes and, once the retries are
+// exhausted, appends the records to a local NDJSON file for later replay.
+type deadLetterWriter struct {
+	w       io.Writer
+	retries int
+	path    string
+	network string // the Logstash network, used for replay
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newDeadLetterWriter(w io.Writer, path string, retries int, network string) (*deadLetterWriter, error) {
+	file, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &deadLetterWriter{w: w, retries: retries, path: path, network: network, file: file}, nil
+}
+
+func (d *deadLetterWriter) Write(p []byte) (int, error) {
+	var err error
+	for attempt := 0; attempt <= d.retries; attempt++ {
+		if _, err = d.w.Write(p); err == nil {
+			return len(p), nil
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, derr := d.file.Write(p); derr != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (d *deadLetterWriter) Flush() error {
+	var err error
+	if f, ok := d.w.(flusher); ok {
+		err = f.Flush()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if serr := d.file.Sync(); err == nil {
+		err = serr
+	}
+	return err
+}
+
+func (d *deadLetterWriter) Close() error {
+	var err error
+	if c, ok := d.w.(io.Closer); ok {
+		err = c.Close()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if ferr := d.file.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// replay sends the dead-lettered records to address over the Logstash
+// network and empties the file once they are all sent. Over udp each record
+// is its own datagram.
+func (d *deadLetterWriter) replay(address string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	data, err := os.ReadFile(d.path)
+	if err != nil || len(data) == 0 {
+		return err
+	}
+
+	conn, err := net.DialTimeout(d.network, address, logstashDialTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	records := [][]byte{data}
+	if d.network == "udp" {
+		records = bytes.SplitAfter(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	}
+	for _, record := range records {
+		if _, err := conn.Write(record); err != nil {
+			return err
+		}
+	}
+	return d.file.Truncate(0)
+}
+
+// ReplayDeadLetter re-sends the records in the configured dead-letter file to
+// the Logstash instance at address, over LogAnalyserNetwork, then empties the
+// file.
+func ReplayDeadLetter(address string) error {
+	if deadLetter == nil {
+		return errors.New("no dead-letter file configured")
+	}
+	return deadLetter.replay(address)
+}
@@ -0,0 +1,112 @@
+// deadletter_test.go
+
+package logger
+
+import (
+	"errors"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("sink down") }
+
+func TestReplayDeadLetterUnix(t *testing.T) {
+	path := tempLogFile(t, "dead.ndjson")
+	d, err := newDeadLetterWriter(failingWriter{}, path, 1, "unix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	for _, line := range []string{`{"message":"one"}` + "\n", `{"message":"two"}` + "\n"} {
+		if _, err := d.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ln, lines := lineListener(t, "unix")
+	if err := d.replay(ln.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	if got := receiveMessages(t, lines, 2); !reflect.DeepEqual(got, []string{"one", "two"}) {
+		t.Fatalf("replayed %v", got)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Fatalf("dead-letter file not emptied: %v, %v", info, err)
+	}
+}
+
+func TestDeadLetterAndReplay(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+	path := tempLogFile(t, "dead.ndjson")
+	initTest(t, Config{
+		LogLevel:                  "Info",
+		LogAnalyserAddress:        ln.Addr().String(),
+		LogAnalyserEnabled:        true,
+		LogAnalyserDeadLetterPath: path,
+		LogAnalyserRetries:        1,
+		FallbackToStdout:          Bool(false),
+	})
+
+	// Take the sink down and wait for the writer to notice
+	ln.Close()
+	(<-accepted).Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for LogAnalyserHealthy() {
+		if time.Now().After(deadline) {
+			t.Fatal("writer stayed healthy with the sink gone")
+		}
+		Info("probe")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for i := 0; i < 3; i++ {
+		Info("undelivered " + strconv.Itoa(i))
+	}
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+	var dead []string
+	for _, msg := range messages(readRecords(t, path)) {
+		if strings.HasPrefix(msg, "undelivered") {
+			dead = append(dead, msg)
+		}
+	}
+	want := []string{"undelivered 0", "undelivered 1", "undelivered 2"}
+	if !reflect.DeepEqual(dead, want) {
+		t.Fatalf("dead-letter file has %v, want %v", dead, want)
+	}
+
+	live, lines := lineListener(t, "tcp")
+	if err := ReplayDeadLetter(live.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	var replayed []string
+	for len(replayed) < len(want) {
+		if msg := receiveMessages(t, lines, 1)[0]; strings.HasPrefix(msg, "undelivered") {
+			replayed = append(replayed, msg)
+		}
+	}
+	if !reflect.DeepEqual(replayed, want) {
+		t.Errorf("replayed %v, want %v", replayed, want)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("dead-letter file not emptied: %v, %v", info, err)
+	}
+}
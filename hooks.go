@@ -0,0 +1,116 @@
+// hooks.go
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// goidHook attaches the emitting goroutine's id. Go has no API for the id, so
+// it is parsed from runtime.Stack, which costs a stack capture per record.
+type goidHook struct{}
+
+func (goidHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	e.Uint64("goid", goroutineID())
+}
+
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	// The first line reads "goroutine 123 [running]:"
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+// funcNameHook attaches the fully qualified name of the calling function,
+// skipping the same frames as the caller field.
+type funcNameHook struct {
+	skip int
+}
+
+func (h funcNameHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	// One frame less than zerolog's caller hook, which goes through Event.caller
+	extra, _ := e.GetCtx().Value(callerSkipKey{}).(int)
+	pc, _, _, ok := runtime.Caller(h.skip + 1 + extra)
+	if !ok {
+		return
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		e.Str("func", fn.Name())
+	}
+}
+
+var sequence atomic.Uint64
+
+// seqHook stamps each emitted record with a process-wide sequence number
+// starting at 1, so gaps downstream reveal dropped lines.
+type seqHook struct{}
+
+func (seqHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	e.Uint64("seq", sequence.Add(1))
+}
+
+var startTime time.Time
+
+// uptimeHook adds the milliseconds elapsed since InitLogger.
+type uptimeHook struct{}
+
+func (uptimeHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	e.Int64("uptime_ms", time.Since(startTime).Milliseconds())
+}
+
+type customLevelKey struct{}
+
+func withCustomLevel(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, customLevelKey{}, name)
+}
+
+// eventLevelName returns the name of the level e was logged at, for hooks.
+// Custom levels reach hooks as NoLevel, so their name is taken from the
+// event's context, where emit stores it.
+func eventLevelName(e *zerolog.Event, level zerolog.Level) string {
+	if level != zerolog.NoLevel {
+		return level.String()
+	}
+	name, _ := e.GetCtx().Value(customLevelKey{}).(string)
+	return name
+}
+
+// callerSkipKey carries the extra frames emit passed to Event.CallerSkipFrame,
+// which hooks can't read back from the event.
+type callerSkipKey struct{}
+
+func withCallerSkip(ctx context.Context, skip int) context.Context {
+	return context.WithValue(ctx, callerSkipKey{}, skip)
+}
+
+type timestampKey struct{}
+
+func withTimestamp(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, timestampKey{}, t)
+}
+
+// timestampHook stands in for zerolog's Context.Timestamp so LogAt can supply
+// the time through the event's context. Times are written in UTC here rather
+// than through zerolog.TimestampFunc, which would change every zerolog
+// logger in the process.
+type timestampHook struct{}
+
+func (timestampHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	t, ok := e.GetCtx().Value(timestampKey{}).(time.Time)
+	if !ok {
+		t = time.Now()
+	}
+	e.Time(zerolog.TimestampFieldName, t.UTC())
+}
@@ -0,0 +1,126 @@
+// hooks_test.go
+
+package logger
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestGoroutineID(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, IncludeGoroutineID: true})
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"first", "second"} {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			Info(name)
+		}(name)
+	}
+	wg.Wait()
+	Close()
+
+	first := findRecord(t, path, "first")["goid"]
+	second := findRecord(t, path, "second")["goid"]
+	if first == nil || second == nil || first == second {
+		t.Errorf("goid values %v and %v, want two different ids", first, second)
+	}
+}
+
+func logFromNamedHelper() {
+	Info("from helper")
+}
+
+func TestCallerFuncName(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, CallerFuncName: true})
+
+	logFromNamedHelper()
+	Close()
+
+	record := findRecord(t, path, "from helper")
+	if fn, _ := record["func"].(string); !strings.HasSuffix(fn, ".logFromNamedHelper") {
+		t.Errorf("func = %q, want logFromNamedHelper", fn)
+	}
+	if caller, _ := record["caller"].(string); !strings.Contains(caller, "hooks_test.go") {
+		t.Errorf("caller = %q, want the helper's file", caller)
+	}
+}
+
+func TestSequenceConcurrent(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, IncludeSequence: true})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				Info("numbered")
+			}
+		}()
+	}
+	wg.Wait()
+	Close()
+
+	// Every record in the file is numbered, so the numbers form one
+	// unbroken range
+	seen := make(map[uint64]bool)
+	lo, hi := uint64(math.MaxUint64), uint64(0)
+	for _, record := range readRecords(t, path) {
+		f, ok := record["seq"].(float64)
+		if !ok {
+			t.Fatalf("record without seq: %v", record)
+		}
+		seq := uint64(f)
+		if seen[seq] {
+			t.Fatalf("seq %d repeated", seq)
+		}
+		seen[seq] = true
+		lo, hi = min(lo, seq), max(hi, seq)
+	}
+	if len(seen) < 800 || hi-lo+1 != uint64(len(seen)) {
+		t.Errorf("%d records numbered %d to %d, want an unbroken range", len(seen), lo, hi)
+	}
+}
+
+func TestUptime(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, IncludeUptime: true})
+
+	Info("first")
+	time.Sleep(20 * time.Millisecond)
+	Info("second")
+	Close()
+
+	first, _ := findRecord(t, path, "first")["uptime_ms"].(float64)
+	second, _ := findRecord(t, path, "second")["uptime_ms"].(float64)
+	if second < first+20 {
+		t.Errorf("uptime_ms went from %v to %v over 20ms", first, second)
+	}
+}
+
+func TestLogAt(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, TimestampPrecision: "millis"})
+
+	at := time.Date(2023, 11, 5, 8, 30, 15, 250e6, time.FixedZone("UTC+1", 3600))
+	LogAt(at, zerolog.InfoLevel, "replayed event", "source", "queue")
+	Close()
+
+	record := findRecord(t, path, "replayed event")
+	if got := record["time"]; got != "2023-11-05T07:30:15.250Z" {
+		t.Errorf("time = %v, want the supplied time in UTC", got)
+	}
+	if record["source"] != "queue" {
+		t.Errorf("got %v", record)
+	}
+}
@@ -0,0 +1,108 @@
+// httpwriter.go
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const defaultHTTPPushBatchSize = 100
+
+var httpPushWriter io.WriteCloser
+
+// PayloadEncoder turns a batch of JSON records into an HTTP request body and
+// its content type.
+type PayloadEncoder func(records [][]byte) (body []byte, contentType string, err error)
+
+// JSONArrayEncoder sends the batch as a plain JSON array of records.
+func JSONArrayEncoder(records [][]byte) ([]byte, string, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, r := range records {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(r)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), "application/json", nil
+}
+
+// LokiEncoder sends the batch as a single Loki push API stream with the
+// given labels. Each entry is stamped with the record's own time.
+func LokiEncoder(labels map[string]string) PayloadEncoder {
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	return func(records [][]byte) ([]byte, string, error) {
+		s := stream{Stream: labels, Values: make([][2]string, 0, len(records))}
+		for _, r := range records {
+			s.Values = append(s.Values, [2]string{strconv.FormatInt(recordTime(r).UnixNano(), 10), string(r)})
+		}
+		body, err := json.Marshal(map[string][]stream{"streams": {s}})
+		return body, "application/json", err
+	}
+}
+
+func recordTime(record []byte) time.Time {
+	var r struct {
+		Time string `json:"time"`
+	}
+	if json.Unmarshal(record, &r) == nil {
+		if t, err := time.Parse(zerolog.TimeFieldFormat, r.Time); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
+
+// HTTPWriter posts newline-delimited records to an HTTP endpoint, encoding
+// each Write as one request. Wrap it in a batch writer so a request carries
+// many records.
+type HTTPWriter struct {
+	url    string
+	encode PayloadEncoder
+	client *http.Client
+}
+
+func NewHTTPWriter(url string, encode PayloadEncoder) *HTTPWriter {
+	if encode == nil {
+		encode = JSONArrayEncoder
+	}
+	return &HTTPWriter{url: url, encode: encode, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *HTTPWriter) Write(p []byte) (int, error) {
+	var records [][]byte
+	for _, line := range bytes.Split(p, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) > 0 {
+			records = append(records, line)
+		}
+	}
+	if len(records) == 0 {
+		return len(p), nil
+	}
+
+	body, contentType, err := w.encode(records)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := w.client.Post(w.url, contentType, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("http push to %s: %s", w.url, resp.Status)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,66 @@
+// httpwriter_test.go
+
+package logger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var testRecords = [][]byte{
+	[]byte(`{"level":"info","time":"2024-06-01T10:00:00Z","message":"one"}`),
+	[]byte(`{"level":"warn","time":"2024-06-01T10:00:01Z","message":"two"}`),
+}
+
+func TestJSONArrayEncoder(t *testing.T) {
+	body, contentType, err := JSONArrayEncoder(testRecords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"level":"info","time":"2024-06-01T10:00:00Z","message":"one"},{"level":"warn","time":"2024-06-01T10:00:01Z","message":"two"}]`
+	if string(body) != want || contentType != "application/json" {
+		t.Errorf("got %s (%s), want %s", body, contentType, want)
+	}
+}
+
+func TestLokiEncoder(t *testing.T) {
+	// Entry times are parsed in the format InitLogger configured
+	prev := zerolog.TimeFieldFormat
+	zerolog.TimeFieldFormat = time.RFC3339
+	defer func() { zerolog.TimeFieldFormat = prev }()
+
+	body, contentType, err := LokiEncoder(map[string]string{"service": "checkout"})(testRecords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"streams":[{"stream":{"service":"checkout"},"values":[` +
+		`["1717236000000000000","{\"level\":\"info\",\"time\":\"2024-06-01T10:00:00Z\",\"message\":\"one\"}"],` +
+		`["1717236001000000000","{\"level\":\"warn\",\"time\":\"2024-06-01T10:00:01Z\",\"message\":\"two\"}"]]}]}`
+	if string(body) != want || contentType != "application/json" {
+		t.Errorf("got %s (%s), want %s", body, contentType, want)
+	}
+}
+
+func TestHTTPWriterPostsBatch(t *testing.T) {
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	w := NewHTTPWriter(server.URL, nil)
+	if _, err := w.Write(bytes.Join(testRecords, []byte("\n"))); err != nil {
+		t.Fatal(err)
+	}
+	want, _, _ := JSONArrayEncoder(testRecords)
+	if got := <-bodies; got != string(want) {
+		t.Errorf("posted %s, want %s", got, want)
+	}
+}
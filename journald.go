@@ -0,0 +1,162 @@
+//go:build linux
+
+// journald.go
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/rs/zerolog"
+)
+
+const journaldSocket = "/run/systemd/journal/socket"
+
+func init() {
+	newJournaldWriter = func() (io.WriteCloser, error) {
+		return NewJournaldWriter(journaldSocket)
+	}
+}
+
+// JournaldWriter sends each record to journald over its native datagram
+// protocol, with MESSAGE, a PRIORITY mapped from the level and every other
+// field as an uppercase journald field.
+type JournaldWriter struct {
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+func NewJournaldWriter(socketPath string) (*JournaldWriter, error) {
+	// Left unconnected: Go refuses WriteMsgUnix, needed to pass a file
+	// descriptor, on a connected datagram socket
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &JournaldWriter{conn: conn, addr: &net.UnixAddr{Name: socketPath, Net: "unixgram"}}, nil
+}
+
+func (w *JournaldWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (w *JournaldWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	var record map[string]interface{}
+	if err := json.Unmarshal(p, &record); err != nil {
+		return 0, err
+	}
+	level, name := recordLevel(level, record[zerolog.LevelFieldName])
+
+	var buf bytes.Buffer
+	appendJournaldField(&buf, "PRIORITY", fmt.Sprint(journaldPriority(level, name)))
+	for key, value := range record {
+		name := "MESSAGE"
+		if key != zerolog.MessageFieldName {
+			name = journaldFieldName(key)
+		}
+		if name == "" {
+			continue
+		}
+		s, ok := value.(string)
+		if !ok {
+			b, _ := json.Marshal(value)
+			s = string(b)
+		}
+		appendJournaldField(&buf, name, s)
+	}
+
+	if _, _, err := w.conn.WriteMsgUnix(buf.Bytes(), nil, w.addr); err != nil {
+		// Datagrams are size-limited; journald accepts larger records as a
+		// file descriptor passed alongside an empty datagram
+		if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+			return 0, err
+		}
+		if err := w.sendFile(buf.Bytes()); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *JournaldWriter) sendFile(data []byte) error {
+	file, err := os.CreateTemp("/dev/shm", "journal.")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+	_, _, err = w.conn.WriteMsgUnix(nil, syscall.UnixRights(int(file.Fd())), w.addr)
+	return err
+}
+
+func (w *JournaldWriter) Close() error {
+	return w.conn.Close()
+}
+
+// appendJournaldField writes KEY=value, or for values containing a newline
+// the binary form: KEY, newline, little-endian uint64 length, value.
+func appendJournaldField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if strings.ContainsRune(value, '\n') {
+		buf.WriteByte('\n')
+		binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	} else {
+		buf.WriteByte('=')
+	}
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journaldFieldName uppercases key and replaces anything outside A-Z, 0-9
+// and _ with _. Leading underscores are dropped since those names are
+// reserved for journald's trusted fields.
+func journaldFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, key)
+	name = strings.TrimLeft(name, "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "F_" + name
+	}
+	return name
+}
+
+// journaldPriority maps a level to a syslog priority. Notice has its own
+// syslog priority although it is filtered like Info.
+func journaldPriority(level zerolog.Level, name string) int {
+	if name == LevelNotice {
+		return 5
+	}
+	switch level {
+	case zerolog.PanicLevel:
+		return 0
+	case zerolog.FatalLevel:
+		return 2
+	case zerolog.ErrorLevel:
+		return 3
+	case zerolog.WarnLevel:
+		return 4
+	case zerolog.InfoLevel, zerolog.NoLevel:
+		return 6
+	}
+	return 7
+}
@@ -0,0 +1,95 @@
+//go:build linux
+
+// journald_test.go
+
+package logger
+
+import (
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestJournaldPriority(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w, err := NewJournaldWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	for _, tt := range []struct {
+		level  zerolog.Level
+		record string
+		want   string
+	}{
+		{zerolog.NoLevel, `{"level":"notice","message":"maintenance"}`, "PRIORITY=5\n"},
+		{zerolog.InfoLevel, `{"level":"info","message":"started"}`, "PRIORITY=6\n"},
+		{zerolog.NoLevel, `{"level":"error","message":"failed"}`, "PRIORITY=3\n"},
+	} {
+		if _, err := w.WriteLevel(tt.level, []byte(tt.record)); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 4096)
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); !strings.Contains(got, tt.want) {
+			t.Errorf("%s sent %q, want %q", tt.record, got, tt.want)
+		}
+	}
+}
+
+func TestJournaldFieldEncoding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w, err := NewJournaldWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	record := `{"level":"warn","message":"line one\nline two","request-id":"r1","_private":"x","2fa":true,"count":3}`
+	if _, err := w.WriteLevel(zerolog.WarnLevel, []byte(record)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(buf[:n])
+
+	message := "line one\nline two"
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(message)))
+	for _, want := range []string{
+		"PRIORITY=4\n",
+		"MESSAGE\n" + string(size[:]) + message + "\n",
+		"REQUEST_ID=r1\n",
+		"PRIVATE=x\n",
+		"F_2FA=true\n",
+		"COUNT=3\n",
+		"LEVEL=warn\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("datagram %q lacks %q", got, want)
+		}
+	}
+}
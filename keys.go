@@ -0,0 +1,99 @@
+// keys.go
+
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rs/zerolog"
+)
+
+var normalizeKeys bool
+
+// masker is Config.Masker, applied to every string field before it is written
+var masker MaskFunc
+
+// base64Bytes switches []byte fields from hex to base64
+var base64Bytes bool
+
+// hashKeys are the Config.HashFields whose string values are replaced by a
+// hex SHA-256 of hashSalt and the value, after masking
+var hashKeys map[string]bool
+
+var hashSalt string
+
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(hashSalt + value))
+	return hex.EncodeToString(sum[:])
+}
+
+// fieldTypes is Config.FieldTypes: string values of these keys are
+// converted to "int", "float" or "bool"
+var fieldTypes map[string]string
+
+// appendCoerced adds value converted to typ. A value that doesn't convert is
+// kept as a string and its key listed in "coercion_error".
+func appendCoerced(event *zerolog.Event, key, value, typ string) *zerolog.Event {
+	switch typ {
+	case "int":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return event.Int64(key, n)
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return event.Float64(key, f)
+		}
+	case "bool":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return event.Bool(key, b)
+		}
+	}
+	return event.Str(key, value).Str("coercion_error", key)
+}
+
+func parseHashFields(keys []string) map[string]bool {
+	if len(keys) == 0 {
+		return nil
+	}
+	m := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		m[key] = true
+	}
+	return m
+}
+
+func isReservedKey(key string) bool {
+	switch key {
+	case zerolog.TimestampFieldName, zerolog.LevelFieldName, zerolog.MessageFieldName,
+		zerolog.ErrorFieldName, zerolog.CallerFieldName, zerolog.ErrorStackFieldName:
+		return true
+	}
+	return false
+}
+
+// snakeCase converts camelCase and PascalCase keys to snake_case, keeping
+// acronyms together: "userID" becomes "user_id", "HTTPStatus" "http_status".
+func snakeCase(key string) string {
+	if isReservedKey(key) {
+		return key
+	}
+
+	runes := []rune(key)
+	var b strings.Builder
+	b.Grow(len(key) + 4)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && runes[i-1] != '_' &&
+				(!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
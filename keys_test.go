@@ -0,0 +1,147 @@
+// keys_test.go
+
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestSnakeCase(t *testing.T) {
+	for key, want := range map[string]string{
+		"userID":      "user_id",
+		"RequestTime": "request_time",
+		"HTTPStatus":  "http_status",
+		"already_ok":  "already_ok",
+		"message":     "message",
+	} {
+		if got := snakeCase(key); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestNormalizeKeys(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, NormalizeKeys: true})
+	Info("request", "userID", "u1", "RequestTime", "12ms")
+	Close()
+
+	record := findRecord(t, path, "request")
+	if record["user_id"] != "u1" || record["request_time"] != "12ms" {
+		t.Errorf("got %v", record)
+	}
+	for _, key := range []string{"userID", "RequestTime"} {
+		if _, ok := record[key]; ok {
+			t.Errorf("%q kept as is", key)
+		}
+	}
+}
+
+func TestMasker(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{
+		LogLevel:    "Info",
+		LogFilePath: path,
+		Masker: func(key, value string) (string, bool) {
+			if key != "card" || len(value) < 4 {
+				return "", false
+			}
+			return strings.Repeat("*", len(value)-4) + value[len(value)-4:], true
+		},
+	})
+	Info("payment", "card", "4111111111111234", "merchant", "acme")
+	Close()
+
+	record := findRecord(t, path, "payment")
+	if got := record["card"]; got != "************1234" {
+		t.Errorf("card = %v", got)
+	}
+	if got := record["merchant"]; got != "acme" {
+		t.Errorf("merchant = %v, want it unmasked", got)
+	}
+}
+
+func TestByteEncoding(t *testing.T) {
+	payload := []byte{0xde, 0xad, 0xbe, 0xef}
+	for _, tt := range []struct {
+		encoding string
+		want     string
+	}{
+		{"", "deadbeef"},
+		{"hex", "deadbeef"},
+		{"base64", "3q2+7w=="},
+	} {
+		path := tempLogFile(t, "app.log")
+		initTest(t, Config{LogLevel: "Info", LogFilePath: path, ByteEncoding: tt.encoding})
+		Info("packet", "payload", payload)
+		Close()
+
+		record := findRecord(t, path, "packet")
+		if got := record["payload"]; got != tt.want {
+			t.Errorf("encoding %q: payload = %v, want %q", tt.encoding, got, tt.want)
+		}
+		if _, ok := record["fields_error"]; ok {
+			t.Errorf("encoding %q: %v", tt.encoding, record["fields_error"])
+		}
+	}
+}
+
+func TestHashFields(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{
+		LogLevel:    "Info",
+		LogFilePath: path,
+		HashFields:  []string{"user_id"},
+		HashSalt:    "pepper",
+		Masker: func(key, value string) (string, bool) {
+			return strings.ToLower(value), key == "user_id"
+		},
+	})
+	Info("login", "user_id", "U42", "method", "password")
+	Info("logout", "user_id", "U42")
+	Close()
+
+	// The Masker runs first, so the hash is of the lowercased id
+	sum := sha256.Sum256([]byte("pepper" + "u42"))
+	want := hex.EncodeToString(sum[:])
+	login := findRecord(t, path, "login")
+	if got := login["user_id"]; got != want {
+		t.Errorf("user_id = %v, want %v", got, want)
+	}
+	if got := findRecord(t, path, "logout")["user_id"]; got != want {
+		t.Errorf("user_id = %v on the second call, want the same hash %v", got, want)
+	}
+	if got := login["method"]; got != "password" {
+		t.Errorf("method = %v, want it unhashed", got)
+	}
+}
+
+func TestFieldTypes(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{
+		LogLevel:    "Info",
+		LogFilePath: path,
+		FieldTypes:  map[string]string{"status": "int", "ratio": "float", "cached": "bool"},
+	})
+	Info("request", "status", "200", "ratio", "0.5", "cached", "true")
+	Info("bad request", "status", "OK")
+	Close()
+
+	record := findRecord(t, path, "request")
+	for key, want := range map[string]interface{}{"status": 200.0, "ratio": 0.5, "cached": true} {
+		if got := record[key]; got != want {
+			t.Errorf("%s = %#v, want %#v", key, got, want)
+		}
+	}
+	if _, ok := record["coercion_error"]; ok {
+		t.Errorf("coercion_error = %v", record["coercion_error"])
+	}
+
+	bad := findRecord(t, path, "bad request")
+	if bad["status"] != "OK" || bad["coercion_error"] != "status" {
+		t.Errorf("got %v, want the string kept and a coercion_error", bad)
+	}
+}
@@ -0,0 +1,131 @@
+// level.go
+
+package logger
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Level names accepted by Config.LogLevel, NewLogger and SetLevel. Matching
+// is case-insensitive.
+const (
+	LevelTrace = "trace"
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+	LevelFatal = "fatal"
+	LevelPanic = "panic"
+)
+
+// LevelNotice is a custom level for operational messages between Info and
+// Warn. It is registered by default and filtered like Info.
+const LevelNotice = "notice"
+
+// customLevels maps an upper-cased custom level name to the zerolog level its
+// records are filtered at.
+var customLevels sync.Map
+
+func init() {
+	RegisterLevel(LevelNotice, zerolog.InfoLevel)
+	activeLevel.Store(int32(zerolog.TraceLevel))
+}
+
+// RegisterLevel adds a custom level name. Records logged at it through
+// LogCustom carry the lower-cased name as their level field and are shown
+// whenever filterAs is enabled. Config.LogLevel and SetLevel accept the name
+// too, meaning filterAs.
+func RegisterLevel(name string, filterAs zerolog.Level) {
+	customLevels.Store(strings.ToUpper(name), filterAs)
+}
+
+func lookupCustomLevel(name string) (zerolog.Level, bool) {
+	l, ok := customLevels.Load(strings.ToUpper(name))
+	if !ok {
+		return zerolog.InfoLevel, false
+	}
+	return l.(zerolog.Level), true
+}
+
+// recordLevel resolves the level of a record handed to a writer. Custom
+// levels are logged as NoLevel, so then the record's level field is looked
+// up instead, as a zerolog level or a registered name. name is the level as
+// logged, e.g. "notice".
+func recordLevel(level zerolog.Level, field interface{}) (resolved zerolog.Level, name string) {
+	if level != zerolog.NoLevel {
+		return level, level.String()
+	}
+	name, _ = field.(string)
+	if l, err := zerolog.ParseLevel(name); err == nil && name != "" {
+		return l, name
+	}
+	if l, ok := lookupCustomLevel(name); ok {
+		return l, name
+	}
+	return zerolog.NoLevel, name
+}
+
+// DefaultLevel is used when LogLevel is empty.
+const DefaultLevel = LevelInfo
+
+// LevelNames returns the accepted level names from least to most severe, e.g.
+// for building CLI flag help.
+func LevelNames() []string {
+	return []string{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError, LevelFatal, LevelPanic}
+}
+
+// activeLevel is the minimum level this package emits. It is kept here rather
+// than in zerolog's global level so other zerolog loggers in the process,
+// such as a dependency's, are not filtered by LogLevel. log.Logger applies it
+// through levelSampler.
+var activeLevel atomic.Int32
+
+func currentLevel() zerolog.Level {
+	return zerolog.Level(activeLevel.Load())
+}
+
+// levelSampler drops records below activeLevel before passing the rest to
+// next. It makes log.Logger itself honour LogLevel, so direct zerolog/log
+// calls and the zerolog.Ctx fallback set by SyncGlobal are filtered like this
+// package's helpers. Being filtered isn't counted as dropped by sampling.
+type levelSampler struct {
+	next zerolog.Sampler
+}
+
+func (s levelSampler) Sample(lvl zerolog.Level) bool {
+	if lvl < currentLevel() {
+		return false
+	}
+	return s.next == nil || s.next.Sample(lvl)
+}
+
+// SetLevel changes the active log level at runtime. It is safe to call while
+// other goroutines are logging. With Config.LevelWarmup set, lowering the
+// level ramps the newly enabled levels in over the warm-up window.
+func SetLevel(level string) {
+	next := parseLogLevel(level)
+	prev := zerolog.Level(activeLevel.Swap(int32(next)))
+	if levelWarmup > 0 && next < prev {
+		warmupState.Store(&warmup{start: time.Now(), from: prev})
+	}
+}
+
+// GetLevel returns the active log level.
+func GetLevel() zerolog.Level {
+	return currentLevel()
+}
+
+// WithTemporaryLevel runs fn with the log level set to level and restores the
+// previous level afterwards, even if fn panics. Overlapping calls from
+// different goroutines restore in whatever order they finish.
+func WithTemporaryLevel(level string, fn func()) {
+	prev := activeLevel.Swap(int32(parseLogLevel(level)))
+	defer activeLevel.Store(prev)
+
+	fn()
+}
@@ -0,0 +1,177 @@
+// level_test.go
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func TestWithTemporaryLevel(t *testing.T) {
+	logPath := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: logPath})
+
+	Debug("hidden")
+	WithTemporaryLevel("debug", func() {
+		Debug("shown")
+	})
+	Debug("hidden again")
+
+	if GetLevel() != zerolog.InfoLevel {
+		t.Fatalf("level is %v after WithTemporaryLevel", GetLevel())
+	}
+	Flush()
+	if got := messages(readRecords(t, logPath)); !reflect.DeepEqual(got, []string{"shown"}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestWithTemporaryLevelRestoresOnPanic(t *testing.T) {
+	initTest(t, Config{LogLevel: "Warn", LogFilePath: tempLogFile(t, "app.log")})
+	func() {
+		defer func() { recover() }()
+		WithTemporaryLevel("trace", func() { panic("boom") })
+	}()
+	if GetLevel() != zerolog.WarnLevel {
+		t.Fatalf("level is %v after a panic", GetLevel())
+	}
+}
+
+func TestLevelScopedToPackage(t *testing.T) {
+	initTest(t, Config{LogLevel: "Error", LogFilePath: tempLogFile(t, "app.log")})
+	SetLevel("panic")
+
+	// A dependency's own zerolog logger is not filtered by this package
+	var buf bytes.Buffer
+	other := zerolog.New(&buf)
+	other.Debug().Msg("from a dependency")
+	if buf.Len() == 0 {
+		t.Fatal("LogLevel filtered another zerolog logger")
+	}
+}
+
+func TestGlobalLoggerFiltered(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Error", LogFilePath: path})
+
+	log.Debug().Msg("direct debug")
+	zerolog.Ctx(context.Background()).Info().Msg("context info")
+	log.Error().Msg("direct error")
+	SetLevel("debug")
+	log.Debug().Msg("debug after SetLevel")
+	Flush()
+
+	want := []string{"direct error", "debug after SetLevel"}
+	if got := messages(readRecords(t, path)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	logPath := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: logPath})
+
+	SetLevel("error")
+	Warn("dropped")
+	SetLevel("debug")
+	Debug("kept")
+	Flush()
+	if got := messages(readRecords(t, logPath)); !reflect.DeepEqual(got, []string{"kept"}) {
+		t.Fatalf("got %v", got)
+	}
+}
+func TestNoticeLevel(t *testing.T) {
+	logPath := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: logPath})
+
+	Notice("maintenance window")
+	SetLevel("warn")
+	Notice("filtered")
+	Flush()
+
+	records := readRecords(t, logPath)
+	if len(records) != 1 || records[0]["level"] != "notice" {
+		t.Fatalf("got %v", records)
+	}
+}
+
+func TestRecordLevel(t *testing.T) {
+	for _, tt := range []struct {
+		level     zerolog.Level
+		field     interface{}
+		wantLevel zerolog.Level
+		wantName  string
+	}{
+		{zerolog.WarnLevel, "warn", zerolog.WarnLevel, "warn"},
+		{zerolog.NoLevel, "notice", zerolog.InfoLevel, "notice"},
+		{zerolog.NoLevel, "error", zerolog.ErrorLevel, "error"},
+		{zerolog.NoLevel, nil, zerolog.NoLevel, ""},
+	} {
+		level, name := recordLevel(tt.level, tt.field)
+		if level != tt.wantLevel || name != tt.wantName {
+			t.Errorf("recordLevel(%v, %v) = %v, %q; want %v, %q", tt.level, tt.field, level, name, tt.wantLevel, tt.wantName)
+		}
+	}
+}
+
+func TestLevelWarmup(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, LevelWarmup: time.Hour, LevelWarmupRate: 10})
+
+	SetLevel("Debug")
+	for i := 0; i < 100; i++ {
+		Debug("newly enabled")
+		Info("already enabled")
+	}
+	Close()
+
+	// At the start of the window 1 in 10 of the new Debug records is kept;
+	// Info was on before and is untouched
+	if got := countMessages(t, path, "newly enabled"); got != 10 {
+		t.Errorf("kept %d of 100 Debug records during warm-up, want 10", got)
+	}
+	if got := countMessages(t, path, "already enabled"); got != 100 {
+		t.Errorf("kept %d of 100 Info records, want all", got)
+	}
+}
+
+func TestLevelWarmupEnds(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, LevelWarmup: 20 * time.Millisecond})
+
+	SetLevel("Debug")
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < 100; i++ {
+		Debug("after warm-up")
+	}
+	Close()
+
+	if got := countMessages(t, path, "after warm-up"); got != 100 {
+		t.Errorf("kept %d of 100 Debug records after warm-up, want all", got)
+	}
+}
+
+func TestLevelNames(t *testing.T) {
+	for _, name := range LevelNames() {
+		level, ok := lookupLogLevel(name)
+		if !ok {
+			t.Errorf("%q isn't accepted", name)
+			continue
+		}
+		if got := parseLogLevel(name); got != level || got.String() != name {
+			t.Errorf("parseLogLevel(%q) = %v", name, got)
+		}
+	}
+	if got := parseLogLevel(""); got.String() != DefaultLevel {
+		t.Errorf("parseLogLevel(\"\") = %v, want %s", got, DefaultLevel)
+	}
+	if _, err := NewLogger("test", true, "", "", "", LevelWarn, false); err != nil {
+		t.Errorf("NewLogger rejected %q: %v", LevelWarn, err)
+	}
+}
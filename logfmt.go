@@ -0,0 +1,79 @@
+// logfmt.go
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// errNotObject is returned by writers re-encoding records that aren't a JSON
+// object, such as a "[1,2]" passed to Writer.
+var errNotObject = errors.New("record is not a JSON object")
+
+// logfmtWriter re-encodes each JSON record as a logfmt line, keeping the
+// record's key order.
+type logfmtWriter struct {
+	w io.Writer
+}
+
+func (l logfmtWriter) Write(p []byte) (int, error) {
+	line, err := jsonToLogfmt(p)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := l.w.Write(line); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func jsonToLogfmt(p []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, errNotObject
+	}
+
+	var buf bytes.Buffer
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, errNotObject
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(logfmtValue(raw))
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func logfmtValue(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		// Numbers, booleans, objects and arrays keep their JSON form
+		s = string(raw)
+	}
+	// Quoting also escapes newlines, keeping the record on one line
+	if s == "" || strings.ContainsAny(s, " =\"\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+// logfmt_test.go
+
+package logger
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLogfmtMultilineMessage(t *testing.T) {
+	logfmtPath := tempLogFile(t, "app.logfmt")
+	consolePath := tempLogFile(t, "app.console")
+	jsonPath := tempLogFile(t, "app.json")
+	initTest(t, Config{
+		LogLevel:    "Info",
+		LogFilePath: jsonPath,
+		ExtraOutputs: []OutputSpec{
+			{Path: logfmtPath, Format: "logfmt", Level: "warn"},
+			{Path: consolePath, Format: "console", Level: "warn"},
+		},
+	})
+
+	Warn("first line\nsecond line", "detail", "a\nb")
+	Close()
+
+	for _, path := range []string{logfmtPath, consolePath} {
+		out, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := strings.Count(string(out), "\n"); lines != 1 || !strings.Contains(string(out), `first line\nsecond line`) {
+			t.Errorf("%s: %d lines, want the record on one line: %q", path, lines, out)
+		}
+	}
+	if got := findRecord(t, jsonPath, "first line\nsecond line")["detail"]; got != "a\nb" {
+		t.Errorf("JSON detail = %q, want the newline kept", got)
+	}
+}
+
+func TestLogfmtNonObject(t *testing.T) {
+	for _, input := range []string{"[1,2]\n", `"text"`, "42"} {
+		var buf strings.Builder
+		if _, err := (logfmtWriter{w: &buf}).Write([]byte(input)); err != errNotObject {
+			t.Errorf("%q: got %v, want %v", input, err, errNotObject)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%q: wrote %q", input, buf.String())
+		}
+	}
+
+	// The failing output must not stop the record reaching the others
+	initTest(t, Config{
+		LogLevel:     "Info",
+		ExtraOutputs: []OutputSpec{{Path: tempLogFile(t, "app.logfmt"), Format: "logfmt"}},
+	})
+	var sink recordingWriter
+	defer AddOutput(&sink)()
+	Writer().Write([]byte("[1,2]\n"))
+	if got := sink.String(); got != "[1,2]\n" {
+		t.Errorf("later output got %q", got)
+	}
+}
@@ -0,0 +1,234 @@
+// logger.go
+
+package logger
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Logger is an instance logger carrying its own bound fields. A Logger created
+// with With writes through the package logger configured by InitLogger.
+type Logger struct {
+	zl     *zerolog.Logger
+	fields []interface{}
+}
+
+func With(fields ...interface{}) *Logger {
+	return &Logger{fields: fields}
+}
+
+func NewFromZerolog(zl zerolog.Logger) *Logger {
+	return &Logger{zl: &zl}
+}
+
+func (l *Logger) With(fields ...interface{}) *Logger {
+	return &Logger{zl: l.zl, fields: l.bind(fields)}
+}
+
+// Go runs fn in a new goroutine with a Logger carrying l's bound fields, so
+// background work logs the parent's request_id:
+//
+//	reqLog.Go(func(l *logger.Logger) { l.Info("cache warmed") })
+//
+// The logger is passed explicitly because Go has no goroutine-local storage;
+// emulating it by goroutine id would need a global map that leaks entries
+// when a goroutine exits without cleanup and hides which fields a log call
+// carries.
+func (l *Logger) Go(fn func(*Logger)) {
+	child := &Logger{zl: l.zl, fields: l.fields}
+	go fn(child)
+}
+
+// Group returns a Logger that nests fields under name, e.g.
+// Group("http", "method", "GET", "status", "200") logs
+// {"http":{"method":"GET","status":"200"}}.
+func Group(name string, fields ...interface{}) *Logger {
+	return With(name, fieldGroup(fields))
+}
+
+func (l *Logger) Group(name string, fields ...interface{}) *Logger {
+	return l.With(name, fieldGroup(fields))
+}
+
+// fieldGroup is a nested object; its pairs follow the same rules as
+// top-level fields.
+type fieldGroup []interface{}
+
+func (g fieldGroup) MarshalZerologObject(e *zerolog.Event) {
+	appendFields(e, g)
+}
+
+func (l *Logger) logger() *zerolog.Logger {
+	if l.zl != nil {
+		return l.zl
+	}
+	return &log.Logger
+}
+
+func (l *Logger) bind(fields []interface{}) []interface{} {
+	if len(l.fields) == 0 {
+		return fields
+	}
+	return append(l.fields[:len(l.fields):len(l.fields)], fields...)
+}
+
+func (l *Logger) log(level zerolog.Level, message string, fields []interface{}) {
+	emit(l.logger(), time.Time{}, level, message, l.bind(fields), 0)
+}
+
+func (l *Logger) logAt(t time.Time, level zerolog.Level, message string, fields []interface{}) {
+	emit(l.logger(), t, level, message, l.bind(fields), 0)
+}
+
+func (l *Logger) logWithError(level zerolog.Level, message string, err error, fields []interface{}) {
+	if err == nil {
+		return
+	}
+	if message == "" {
+		message = err.Error()
+	}
+	fields = appendJoined(fields, err)
+	if wantStack(err) {
+		err = errors.WithStack(err)
+	}
+	emit(l.logger(), time.Time{}, level, message, l.bind(append(fields, "error", err)), 0)
+}
+
+func (l *Logger) Log(level zerolog.Level, message string, fields ...interface{}) {
+	l.log(level, message, fields)
+}
+
+func (l *Logger) LogAt(t time.Time, level zerolog.Level, message string, fields ...interface{}) {
+	l.logAt(t, level, message, fields)
+}
+
+func (l *Logger) Info(message string, fields ...interface{}) {
+	l.log(zerolog.InfoLevel, message, fields)
+}
+
+func (l *Logger) Debug(message string, fields ...interface{}) {
+	l.log(zerolog.DebugLevel, message, fields)
+}
+
+func (l *Logger) Warn(message string, fields ...interface{}) {
+	l.log(zerolog.WarnLevel, message, fields)
+}
+
+func (l *Logger) Error(message string, fields ...interface{}) {
+	l.log(zerolog.ErrorLevel, message, fields)
+}
+
+func (l *Logger) Fatal(message string, fields ...interface{}) {
+	l.log(zerolog.FatalLevel, message, fields)
+}
+
+func (l *Logger) Panic(message string, fields ...interface{}) {
+	l.log(zerolog.PanicLevel, message, fields)
+}
+
+func (l *Logger) Trace(message string, fields ...interface{}) {
+	l.log(zerolog.TraceLevel, message, fields)
+}
+
+// InfoIf logs only when cond is true, like the package-level InfoIf.
+func (l *Logger) InfoIf(cond bool, message string, fields ...interface{}) {
+	if cond {
+		l.log(zerolog.InfoLevel, message, fields)
+	}
+}
+
+func (l *Logger) DebugIf(cond bool, message string, fields ...interface{}) {
+	if cond {
+		l.log(zerolog.DebugLevel, message, fields)
+	}
+}
+
+func (l *Logger) WarnIf(cond bool, message string, fields ...interface{}) {
+	if cond {
+		l.log(zerolog.WarnLevel, message, fields)
+	}
+}
+
+func (l *Logger) ErrorIf(cond bool, message string, fields ...interface{}) {
+	if cond {
+		l.log(zerolog.ErrorLevel, message, fields)
+	}
+}
+
+func (l *Logger) TraceIf(cond bool, message string, fields ...interface{}) {
+	if cond {
+		l.log(zerolog.TraceLevel, message, fields)
+	}
+}
+
+func (l *Logger) Notice(message string, fields ...interface{}) {
+	logCustom(l.logger(), LevelNotice, message, l.bind(fields))
+}
+
+func (l *Logger) LogCustom(level, message string, fields ...interface{}) {
+	logCustom(l.logger(), level, message, l.bind(fields))
+}
+
+func (l *Logger) WarnWithError(err error, fields ...interface{}) {
+	l.logWithError(zerolog.WarnLevel, "", err, fields)
+}
+
+func (l *Logger) ErrorWithError(err error, fields ...interface{}) {
+	l.logWithError(zerolog.ErrorLevel, "", err, fields)
+}
+
+func (l *Logger) FatalWithError(err error, fields ...interface{}) {
+	l.logWithError(zerolog.FatalLevel, "", err, fields)
+}
+
+func (l *Logger) PanicWithError(err error, fields ...interface{}) {
+	l.logWithError(zerolog.PanicLevel, "", err, fields)
+}
+
+func (l *Logger) TraceWithError(err error, fields ...interface{}) {
+	l.logWithError(zerolog.TraceLevel, "", err, fields)
+}
+
+// ErrorMsg logs err under a fixed message, like the package-level ErrorMsg.
+func (l *Logger) ErrorMsg(message string, err error, fields ...interface{}) {
+	l.logWithError(zerolog.ErrorLevel, message, err, fields)
+}
+
+func (l *Logger) TraceMsg(message string, err error, fields ...interface{}) {
+	l.logWithError(zerolog.TraceLevel, message, err, fields)
+}
+
+func (l *Logger) DebugMsg(message string, err error, fields ...interface{}) {
+	l.logWithError(zerolog.DebugLevel, message, err, fields)
+}
+
+func (l *Logger) InfoMsg(message string, err error, fields ...interface{}) {
+	l.logWithError(zerolog.InfoLevel, message, err, fields)
+}
+
+func (l *Logger) WarnMsg(message string, err error, fields ...interface{}) {
+	l.logWithError(zerolog.WarnLevel, message, err, fields)
+}
+
+func (l *Logger) FatalMsg(message string, err error, fields ...interface{}) {
+	l.logWithError(zerolog.FatalLevel, message, err, fields)
+}
+
+func (l *Logger) PanicMsg(message string, err error, fields ...interface{}) {
+	l.logWithError(zerolog.PanicLevel, message, err, fields)
+}
+
+func (l *Logger) ErrorReturn(err error, fields ...interface{}) error {
+	l.logWithError(zerolog.ErrorLevel, "", err, fields)
+	return err
+}
+
+func (l *Logger) WarnReturn(err error, fields ...interface{}) error {
+	l.logWithError(zerolog.WarnLevel, "", err, fields)
+	return err
+}
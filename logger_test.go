@@ -0,0 +1,76 @@
+// logger_test.go
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestGroup(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	Group("http", "method", "GET", "status", "200").Info("request", "route", "/orders")
+	Group("bad", "odd").Info("uneven")
+	Close()
+
+	record := findRecord(t, path, "request")
+	if want := map[string]interface{}{"method": "GET", "status": "200"}; !reflect.DeepEqual(record["http"], want) {
+		t.Errorf("http = %v, want %v", record["http"], want)
+	}
+	if record["route"] != "/orders" {
+		t.Errorf("route = %v", record["route"])
+	}
+	if bad, _ := findRecord(t, path, "uneven")["bad"].(map[string]interface{}); bad["fields_error"] == nil {
+		t.Errorf("uneven group got %v, want a fields_error inside it", bad)
+	}
+}
+
+func TestLoggerGo(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	done := make(chan struct{})
+	With("request_id", "r1").Go(func(l *Logger) {
+		defer close(done)
+		l.Info("cache warmed")
+	})
+	<-done
+	Close()
+
+	if got := findRecord(t, path, "cache warmed")["request_id"]; got != "r1" {
+		t.Errorf("request_id = %v, want the parent's r1", got)
+	}
+}
+
+func TestLoggerErrorWithError(t *testing.T) {
+	initTest(t, Config{LogLevel: "Info", Console: true})
+
+	var buf bytes.Buffer
+	l := NewFromZerolog(zerolog.New(&buf)).With("component", "billing")
+	l.ErrorWithError(nil)
+	if buf.Len() != 0 {
+		t.Fatalf("nil error logged %q", buf.String())
+	}
+	l.ErrorWithError(errors.New("charge declined"), "order", "o1")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("%q: %v", buf.String(), err)
+	}
+	if record["message"] != "charge declined" || record["error"] != "charge declined" || record["level"] != "error" {
+		t.Errorf("got %v", record)
+	}
+	if record["component"] != "billing" || record["order"] != "o1" {
+		t.Errorf("fields missing: %v", record)
+	}
+	if _, ok := record["stack"]; !ok {
+		t.Errorf("no stack: %v", record)
+	}
+}
@@ -3,35 +3,208 @@
 package logger
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog/pkgerrors"
 )
 
 var initialized bool
 
+// callerSkipFrameCount skips the wrapper, logWithFields and emit frames.
+const callerSkipFrameCount = 5
+
+var logstashWriter *LogstashWriter
+
+var analyserWriter io.WriteCloser
+
+var logFile *reopenFile
+
+// outputWriter starts as zerolog's default destination until InitLogger runs
+var outputWriter io.Writer = os.Stderr
+
+var errorFile *reopenFile
+
 type LogstashWriter struct {
-	conn net.Conn
+	network string
+	address string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	closed  bool
+	healthy atomic.Bool
+
+	// Circuit breaker: after threshold consecutive failures, writes fail fast
+	// for cooldown before a single write is let through to probe the sink.
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+
+	// After a failed dial, writes fail fast until nextDial; the wait doubles
+	// with each failed dial up to maxRedialBackoff
+	backoff  time.Duration
+	nextDial time.Time
+
+	onStateChange ConnectionStateFunc
 }
 
+const defaultBreakerCooldown = 10 * time.Second
+
+// logstashWriteTimeout bounds a single write to the Logstash connection.
+const logstashWriteTimeout = 5 * time.Second
+
+// logstashDialTimeout bounds a connect attempt, which runs with the writer
+// locked, so an unreachable host can't stall every logging goroutine.
+const logstashDialTimeout = 2 * time.Second
+
+const (
+	minRedialBackoff = 100 * time.Millisecond
+	maxRedialBackoff = 30 * time.Second
+)
+
+var ErrCircuitOpen = errors.New("logstash circuit breaker is open")
+
+// ErrRedialBackoff is returned while the writer waits before redialing after
+// a failed connect.
+var ErrRedialBackoff = errors.New("logstash reconnect pending")
+
 func NewLogstashWriter(network, address string) (*LogstashWriter, error) {
-	conn, err := net.Dial(network, address)
+	if network == "tcp" || network == "udp" {
+		if err := validateAddress(address); err != nil {
+			return nil, err
+		}
+	}
+	// net.Dial tries every address a hostname resolves to, A and AAAA, in
+	// turn until one connects
+	conn, err := net.DialTimeout(network, address, logstashDialTimeout)
 
 	if err != nil {
 		return nil, err
 	}
-	return &LogstashWriter{conn: conn}, nil
+	w := &LogstashWriter{network: network, address: address, conn: conn}
+	w.healthy.Store(true)
+	return w, nil
+}
+
+func (w *LogstashWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	was := w.healthy.Load()
+	n, err := w.write(p)
+	onChange := w.onStateChange
+	w.mu.Unlock()
+
+	// Called without the lock so the callback may log or use the writer
+	if now := w.healthy.Load(); onChange != nil && now != was {
+		onChange(now)
+	}
+	return n, err
+}
+
+func (w *LogstashWriter) write(p []byte) (n int, err error) {
+	if w.closed {
+		return 0, net.ErrClosed
+	}
+
+	if w.threshold > 0 && w.failures >= w.threshold && time.Since(w.openedAt) < w.cooldown {
+		return 0, ErrCircuitOpen
+	}
+
+	// A failed write drops the connection; the next write redials
+	if w.conn == nil {
+		if time.Now().Before(w.nextDial) {
+			return 0, ErrRedialBackoff
+		}
+		conn, err := net.DialTimeout(w.network, w.address, logstashDialTimeout)
+		if err != nil {
+			w.fail()
+			w.backoff = min(max(2*w.backoff, minRedialBackoff), maxRedialBackoff)
+			w.nextDial = time.Now().Add(w.backoff)
+			return 0, err
+		}
+		w.conn = conn
+		w.backoff = 0
+	}
+
+	// Bounded so a peer that stops reading can't hold mu, and Close, forever
+	w.conn.SetWriteDeadline(time.Now().Add(logstashWriteTimeout))
+	n, err = w.conn.Write(p)
+	if err != nil {
+		w.conn.Close()
+		w.conn = nil
+		w.fail()
+		return n, err
+	}
+	w.failures = 0
+	w.healthy.Store(true)
+	return n, nil
+}
+
+// SetCircuitBreaker makes the writer stop dialing for cooldown after threshold
+// consecutive failures. A threshold of 0 disables the breaker.
+func (w *LogstashWriter) SetCircuitBreaker(threshold int, cooldown time.Duration) {
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.threshold = threshold
+	w.cooldown = cooldown
+}
+
+// OnStateChange sets a func called with false when the connection drops and
+// true when a write succeeds again. It runs on the writing goroutine.
+func (w *LogstashWriter) OnStateChange(fn ConnectionStateFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onStateChange = fn
+}
+
+func (w *LogstashWriter) fail() {
+	w.healthy.Store(false)
+	w.failures++
+	if w.threshold > 0 && w.failures >= w.threshold {
+		w.openedAt = time.Now()
+	}
+}
+
+func (w *LogstashWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	w.healthy.Store(false)
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
 }
 
-func (w *LogstashWriter) Write(p []byte) (n int, err error) {
-	return w.conn.Write(p)
+// Healthy reports whether the writer currently holds a live connection. It
+// is false from a failed write until a redial succeeds.
+func (w *LogstashWriter) Healthy() bool {
+	return w.healthy.Load()
+}
+
+// LogAnalyserHealthy reports whether the Logstash connection is currently live.
+// It returns false when no log analyser is configured.
+func LogAnalyserHealthy() bool {
+	return logstashWriter != nil && logstashWriter.Healthy()
 }
 
 func InitLogger(config Config) {
@@ -40,7 +213,15 @@ func InitLogger(config Config) {
 		return
 	}
 
-	zerolog.TimeFieldFormat = time.RFC3339
+	if err := config.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Invalid logger config")
+	}
+
+	startTime = time.Now()
+	zerolog.TimeFieldFormat = timeFormat(config.TimestampPrecision)
+	zerolog.DurationFieldUnit = parseDurationUnit(config.DurationUnit)
+	zerolog.DurationFieldInteger = false
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
 
 	var writers []io.Writer
 
@@ -48,95 +229,819 @@ func InitLogger(config Config) {
 	if config.Console {
 		// writers = append(writers, zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}) // Disable ANSI escape codes
 
-		writers = append(writers, zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339})
+		console := newConsoleWriter(zerolog.SyncWriter(consoleStream(config.ConsoleStream)), false)
+		console.FieldsOrder = config.ConsoleFieldOrder
+		if config.ConsoleLocalTime {
+			console.TimeLocation = time.Local
+		}
+		writers = append(writers, console)
+		addSink("console", console)
 	}
 
 	// Add file output if provided
 	if config.LogFilePath != "" {
-		file, err := os.OpenFile(config.LogFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		file, err := newReopenFile(expandPath(config.LogFilePath, time.Now()), config.FileBufferSize)
 		if err != nil {
 			log.Fatal().Err(err).Msg("Failed to open log file")
 		}
-		writers = append(writers, file)
+		// zerolog hands each record to a single Write, and reopenFile locks
+		// per Write, so concurrent records don't interleave in the file
+		var fw io.Writer = file
+		if config.Compact {
+			fw = newCompactWriter(fw)
+		} else if config.PrettyJSON {
+			fw = prettyWriter{w: fw}
+		}
+		writers = append(writers, withFallback(fw, config))
+		logFile = file
+		addSink("log file", file)
 
 		// Store file handle in a package-level variable to ensure it's not closed prematurely
 		log.Logger = log.Logger.Output(file)
 	}
 
+	// Add a dedicated file for Error and above if provided
+	if config.ErrorFilePath != "" {
+		file, err := newReopenFile(config.ErrorFilePath, 0)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to open error log file")
+		}
+		var fw io.Writer = file
+		if config.Compact {
+			fw = newCompactWriter(fw)
+		}
+		writers = append(writers, newLevelFilterWriter(withFallback(fw, config), zerolog.ErrorLevel))
+		addSink("error file", file)
+		errorFile = file
+	}
+
+	if config.AuditFilePath != "" {
+		file, err := newReopenFile(config.AuditFilePath, 0)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to open audit log file")
+		}
+		auditFile = file
+	}
+
+	// Add any extra outputs, each with its own format and minimum level
+	for _, spec := range config.ExtraOutputs {
+		w, err := newOutputWriter(spec, config)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to open extra log output")
+		}
+		writers = append(writers, w)
+		addSink(spec.Path, w)
+	}
+
+	if config.OTLPEndpoint != "" {
+		if newOTLPWriter == nil {
+			log.Fatal().Msg("OTLP export requires building with the otlp tag")
+		}
+		w, err := newOTLPWriter(config.OTLPEndpoint, config.OTLPProtocol, config.OTLPInsecure)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create OTLP writer")
+		}
+		otlpWriter = w
+		writers = append(writers, w)
+		addSink("otlp", w)
+	}
+
+	if config.ProtoIngestAddress != "" {
+		if newProtoWriter == nil {
+			log.Fatal().Msg("Protobuf ingestion requires building with the proto tag and calling SetProtoStream")
+		}
+		w, err := newProtoWriter(config.ProtoIngestAddress)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create protobuf ingestion writer")
+		}
+		protoWriter = w
+		writers = append(writers, w)
+		addSink("proto ingest", w)
+	}
+
+	if config.HTTPPushURL != "" {
+		encoder := config.HTTPPayloadEncoder
+		if encoder == nil {
+			encoder = LokiEncoder(map[string]string{"service": config.ServiceName})
+		}
+		batchSize := config.HTTPPushBatchSize
+		if batchSize <= 0 {
+			batchSize = defaultHTTPPushBatchSize
+		}
+		hw := NewHTTPWriter(config.HTTPPushURL, encoder)
+		httpPushWriter = newBatchWriter(hw, batchSize, config.HTTPPushFlushInterval)
+		writers = append(writers, httpPushWriter)
+		addSink("http push", hw)
+	}
+
+	if config.Journald {
+		if newJournaldWriter == nil {
+			log.Fatal().Msg("Journald output is only supported on Linux")
+		}
+		w, err := newJournaldWriter()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create journald writer")
+		}
+		journaldWriter = w
+		writers = append(writers, w)
+		addSink("journald", w)
+	}
+
+	if config.RingBufferSize > 0 {
+		ringBuffer = NewRingBufferWriter(config.RingBufferSize)
+		writers = append(writers, ringBuffer)
+	}
+
+	if config.UDPAddress != "" {
+		chunker := config.UDPChunker
+		if chunker == nil {
+			chunker = GELFChunker
+		}
+		w, err := NewUDPWriter(config.UDPAddress, config.UDPMaxDatagramSize, chunker)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create UDP writer")
+		}
+		udpWriter = w
+		writers = append(writers, w)
+		addSink("udp", w)
+	}
+
 	if config.LogAnalyserEnabled {
-		logstashWriter, err := NewLogstashWriter("tcp", config.LogAnalyserAddress)
+		network := config.LogAnalyserNetwork
+		if network == "" {
+			network = "tcp"
+		}
+		w, err := NewLogstashWriter(network, config.LogAnalyserAddress)
 
 		if err != nil {
 			log.Fatal().Err(err).Msg("Failed to create Logstash writer")
 		}
 
-		writers = append(writers, logstashWriter)
+		w.SetCircuitBreaker(config.LogAnalyserBreakerThreshold, config.LogAnalyserBreakerCooldown)
+		w.OnStateChange(config.OnConnectionStateChange)
+
+		logstashWriter = w
+		analyserWriter = w
+		addSink("logstash", w)
+		if config.LogAnalyserDeadLetterPath != "" {
+			dl, err := newDeadLetterWriter(w, config.LogAnalyserDeadLetterPath, config.LogAnalyserRetries, network)
+			if err != nil {
+				log.Fatal().Err(err).Msg("Failed to open Logstash dead-letter file")
+			}
+			deadLetter = dl
+			analyserWriter = dl
+		}
+		if isEnabled(config.FallbackToStdout) {
+			analyserWriter = fallbackWriter{w: analyserWriter}
+		}
+		// Over UDP each Write is one datagram and Logstash's udp input
+		// expects one record per datagram, so records are never batched
+		if config.LogAnalyserBatchSize > 1 && network != "udp" {
+			batch := newBatchWriter(analyserWriter, config.LogAnalyserBatchSize, config.LogAnalyserFlushInterval)
+			batch.flushOnError = config.FlushOnError
+			analyserWriter = batch
+		}
+		if config.LogAnalyserAsync {
+			analyserWriter = newAsyncWriter(analyserWriter, config.LogAnalyserQueueSize)
+		}
+		writers = append(writers, analyserWriter)
+	}
+
+	// Combine outputs so level-filtered writers see each record's level and
+	// a failing output doesn't stop the others
+	if len(writers) == 0 {
+		if !config.FallbackStdout {
+			log.Fatal().Msg("No log output is active; set FallbackStdout to write to stdout")
+		}
+		writers = append(writers, os.Stdout)
 	}
+	outputs = newMultiLevelWriter(writers...)
+	var multiWriter io.Writer = outputs
 
-	// Use MultiWriter to combine outputs
-	var multiWriter io.Writer
-	if len(writers) > 0 {
-		multiWriter = io.MultiWriter(writers...)
-	} else {
-		// Default to stdout if no specific output configured
-		multiWriter = os.Stdout
+	if config.ValidateSchema {
+		multiWriter = newSchemaWriter(multiWriter, config.SchemaRequiredKeys)
+	}
+
+	if len(config.AllowedFields) > 0 {
+		multiWriter = newAllowListWriter(multiWriter, config.AllowedFields)
 	}
 
+	outputWriter = multiWriter
+
 	// Convert log level string to zerolog.Level
 	logLevel := parseLogLevel(config.LogLevel)
 
+	ctx := zerolog.New(multiWriter).Hook(timestampHook{}).With()
+
+	if isEnabled(config.IncludeService) {
+		ctx = ctx.Str("service", config.ServiceName)
+	}
+	if isEnabled(config.IncludePod) {
+		ctx = ctx.Str("pod", config.PodName)
+	}
+	if isEnabled(config.IncludePID) {
+		ctx = ctx.Int("pid", os.Getpid())
+	}
+
+	if config.Environment != "" {
+		ctx = ctx.Str("env", config.Environment)
+	}
+
+	if config.IncludeBuildInfo {
+		ctx = withBuildInfo(ctx)
+	}
+
+	if config.IncludeResourceLimits {
+		ctx = withResourceLimits(ctx)
+	}
+
+	// Audit records share the identifying fields but none of the sampling,
+	// hooks or outputs added below
+	auditLogger = ctx.Logger()
+
+	ctx = ctx.CallerWithSkipFrameCount(callerSkipFrameCount)
+
 	// Initialize logger with JSON formatter
-	log.Logger = zerolog.New(multiWriter).With().
-		Timestamp().
-		Str("service", config.ServiceName).
-		Str("pod", config.PodName).
-		Int("pid", os.Getpid()).
-		CallerWithSkipFrameCount(4).
+	log.Logger = ctx.
 		Logger().
-		Level(logLevel).
-		Output(multiWriter) // Use multiWriter for output
+		Output(multiWriter). // Use multiWriter for output
+		Hook(countHook{})
+
+	// Checked in emit rather than set on the logger so SetLevel can change
+	// it atomically at runtime
+	activeLevel.Store(int32(logLevel))
+
+	if config.CallerFuncName {
+		log.Logger = log.Logger.Hook(funcNameHook{skip: callerSkipFrameCount})
+	}
+
+	if config.IncludeSequence {
+		log.Logger = log.Logger.Hook(seqHook{})
+	}
+
+	if config.IncludeUptime {
+		log.Logger = log.Logger.Hook(uptimeHook{})
+	}
+
+	if config.IncludeGoroutineID {
+		log.Logger = log.Logger.Hook(goidHook{})
+	}
 
+	if len(config.Hooks) > 0 {
+		log.Logger = log.Logger.Hook(config.Hooks...)
+	}
+
+	requiredFields = parseRequiredFields(config.RequiredFields)
+	normalizeKeys = config.NormalizeKeys
+	captureStacks = isEnabled(config.CaptureStacks)
+	recoverLogPanics = isEnabled(config.RecoverFromLogPanics)
+	masker = config.Masker
+	hashKeys = parseHashFields(config.HashFields)
+	hashSalt = config.HashSalt
+	fieldTypes = config.FieldTypes
+	logBodies = config.LogBodies
+	bodyLogLimit = config.BodyLogLimit
+	if bodyLogLimit <= 0 {
+		bodyLogLimit = defaultBodyLogLimit
+	}
+	base64Bytes = strings.ToLower(config.ByteEncoding) == "base64"
+
+	if config.SampleByField != "" && config.SampleByFieldLimit > 0 {
+		valueSampler = newFieldSampler(config.SampleByField, config.SampleByFieldLimit, config.SampleByFieldPeriod)
+	}
+
+	if config.TraceSampleRate > 0 && config.TraceSampleRate < 1 {
+		traceIDSampler = &traceSampler{rate: config.TraceSampleRate, seed: config.TraceSampleSeed}
+	}
+
+	var sampler zerolog.Sampler
+	if config.SampleBurst > 0 {
+		sampler = countingSampler{newBurstSampler(config.SampleBurst, config.SampleBurstPeriod, config.SampleRate)}
+	} else if config.SampleRate > 1 {
+		sampler = countingSampler{&zerolog.BasicSampler{N: config.SampleRate}}
+	}
+
+	levelWarmup = config.LevelWarmup
+	levelWarmupRate = config.LevelWarmupRate
+	if levelWarmupRate == 0 {
+		levelWarmupRate = defaultLevelWarmupRate
+	}
+	warmupState.Store(nil)
+	if levelWarmup > 0 {
+		sampler = warmupSampler{next: sampler}
+	}
+
+	log.Logger = log.Logger.Sample(levelSampler{next: sampler})
+
+	// Record the defaults resolved above so CurrentConfig shows what is active
+	config.LogLevel = logLevel.String()
+	config.BodyLogLimit = bodyLogLimit
+	config.LevelWarmupRate = levelWarmupRate
+	currentConfig.Store(&config)
+
+	configured = log.Logger
+	SyncGlobal()
+	outputsClosed = false
+	initialized = true
+}
+
+// currentConfig is the Config from the last successful InitLogger.
+var currentConfig atomic.Pointer[Config]
+
+// CurrentConfig returns a copy of the Config the last successful InitLogger
+// ran with, with LogLevel, BodyLogLimit and LevelWarmupRate showing the
+// values in effect. Slices, maps and funcs are shared with that Config, so
+// treat them as read-only. Before InitLogger, or after InitWithLogger alone,
+// it returns the zero Config.
+func CurrentConfig() Config {
+	if c := currentConfig.Load(); c != nil {
+		return *c
+	}
+	return Config{}
+}
+
+// InitWithLogger adopts a preconfigured zerolog.Logger as the package logger
+// instead of building one from a Config. A caller field on l needs a skip
+// frame count of 5 to point past this package's wrappers.
+func InitWithLogger(l zerolog.Logger) {
+	if initialized {
+		log.Warn().Msg("Logger already initialized, skipping re-initialization")
+		return
+	}
+
+	configured = l
+	SyncGlobal()
 	initialized = true
 }
 
+// configured is the logger built by the last InitLogger or InitWithLogger,
+// kept so SyncGlobal can restore it.
+var configured zerolog.Logger
+
+// SyncGlobal points zerolog/log.Logger, and zerolog.Ctx's fallback for
+// contexts without a logger, at the configured logger again, for when a
+// dependency has replaced the global. InitLogger already sets both.
+//
+// Only code that reads log.Logger at call time is affected: a dependency that
+// copied the logger into its own field before InitLogger keeps writing to
+// the old outputs, so initialize logging before constructing such
+// dependencies. Records logged directly through zerolog/log carry a caller
+// offset for this package's wrappers and may report the wrong frame.
+func SyncGlobal() {
+	log.Logger = configured
+	zerolog.DefaultContextLogger = &configured
+}
+
+// Reinit closes the current outputs and rebuilds the logger from config.
+// Unlike a second InitLogger call, which is ignored, it is meant for
+// deliberate reconfiguration such as a test harness switching setups.
+func Reinit(config Config) {
+	closeErr := Close()
+
+	logstashWriter = nil
+	analyserWriter = nil
+	deadLetter = nil
+	otlpWriter = nil
+	protoWriter = nil
+	httpPushWriter = nil
+	journaldWriter = nil
+	udpWriter = nil
+	ringBuffer = nil
+	auditFile = nil
+	sinks = nil
+	logFile = nil
+	errorFile = nil
+	extraFiles = nil
+	valueSampler = nil
+	traceIDSampler = nil
+	initialized = false
+
+	InitLogger(config)
+
+	if closeErr != nil {
+		log.Warn().Err(closeErr).Msg("Failed to close previous log outputs")
+	}
+}
+
+// Writer returns the combined output (console, files, Logstash) for libraries
+// that want to write pre-formatted lines. Bytes written this way bypass level
+// filtering, sampling and field processing, and reach every output including
+// level-filtered ones.
+func Writer() io.Writer {
+	return outputWriter
+}
+
+func withFallback(w io.Writer, config Config) io.Writer {
+	if isEnabled(config.FallbackToStdout) {
+		return fallbackWriter{w: w}
+	}
+	return w
+}
+
+type flusher interface {
+	Flush() error
+}
+
+// Flush writes out any queued or batched Logstash records and syncs the log
+// files. Everything stays open, so logging can continue afterwards.
+func Flush() error {
+	var err error
+	for _, w := range []io.Writer{analyserWriter, otlpWriter, httpPushWriter} {
+		if f, ok := w.(flusher); ok {
+			if ferr := f.Flush(); err == nil {
+				err = ferr
+			}
+		}
+	}
+	for _, file := range openFiles() {
+		if serr := file.Sync(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// outputsClosed is set by Close and cleared by InitLogger.
+var outputsClosed bool
+
+// Close logs a shutdown summary, flushes pending records and closes the log
+// outputs. A later InitLogger initializes the logger afresh. Calling Close
+// again before that does nothing, so Reinit after the caller's own Close is
+// safe.
+func Close() error {
+	if outputsClosed {
+		return nil
+	}
+	if initialized {
+		LogShutdownSummary()
+	}
+	return closeOutputs()
+}
+
+// closeOutputs is Close after the shutdown summary is logged.
+func closeOutputs() error {
+	outputsClosed = true
+	initialized = false
+	err := Flush()
+	for _, w := range []io.WriteCloser{analyserWriter, otlpWriter, protoWriter, httpPushWriter, journaldWriter, udpWriter} {
+		if w == nil {
+			continue
+		}
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+	}
+	for _, file := range openFiles() {
+		if ferr := file.Close(); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// CloseTimeoutError is returned by CloseWithTimeout when the async Logstash
+// queue could not be drained in time.
+type CloseTimeoutError struct {
+	Dropped int
+}
+
+func (e *CloseTimeoutError) Error() string {
+	return fmt.Sprintf("log queue not drained in time, %d records dropped", e.Dropped)
+}
+
+// CloseWithTimeout is Close that stops waiting for the async Logstash queue
+// after d, so a dead sink can't hang shutdown. The shutdown summary is queued
+// first; records still queued at the deadline are dropped and reported in a
+// *CloseTimeoutError.
+func CloseWithTimeout(d time.Duration) error {
+	if outputsClosed {
+		return nil
+	}
+	deadline := time.Now().Add(d)
+	async, _ := analyserWriter.(*asyncWriter)
+	if async != nil {
+		// A summary blocked on a full queue gives up at the deadline too
+		release := time.AfterFunc(d, async.release)
+		defer release.Stop()
+	}
+	// The summary is queued before the queue is drained and closed
+	if initialized {
+		LogShutdownSummary()
+	}
+	var dropped int
+	var err error
+	if async != nil {
+		dropped, err = async.closeWithin(time.Until(deadline))
+	}
+	if cerr := closeOutputs(); err == nil {
+		err = cerr
+	}
+	if dropped > 0 {
+		return &CloseTimeoutError{Dropped: dropped}
+	}
+	return err
+}
+
 func parseLogLevel(level string) zerolog.Level {
+	if l, ok := lookupLogLevel(level); ok {
+		return l
+	}
+	l, _ := lookupLogLevel(DefaultLevel)
+	return l
+}
+
+func lookupLogLevel(level string) (zerolog.Level, bool) {
 	switch strings.ToUpper(level) {
 	case "DEBUG":
-		return zerolog.DebugLevel
+		return zerolog.DebugLevel, true
 	case "INFO":
-		return zerolog.InfoLevel
+		return zerolog.InfoLevel, true
 	case "WARN":
-		return zerolog.WarnLevel
+		return zerolog.WarnLevel, true
 	case "ERROR":
-		return zerolog.ErrorLevel
+		return zerolog.ErrorLevel, true
 	case "FATAL":
-		return zerolog.FatalLevel
+		return zerolog.FatalLevel, true
 	case "PANIC":
-		return zerolog.PanicLevel
+		return zerolog.PanicLevel, true
 	case "TRACE":
-		return zerolog.TraceLevel
+		return zerolog.TraceLevel, true
+	default:
+		return lookupCustomLevel(level)
+	}
+}
+
+func consoleStream(stream string) *os.File {
+	if strings.ToLower(stream) == "stderr" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// timeFormat returns the RFC3339 layout with a fixed number of fractional
+// digits for precision: "seconds" (default), "millis", "micros" or "nanos".
+func timeFormat(precision string) string {
+	switch strings.ToLower(precision) {
+	case "millis":
+		return "2006-01-02T15:04:05.000Z07:00"
+	case "micros":
+		return "2006-01-02T15:04:05.000000Z07:00"
+	case "nanos":
+		return "2006-01-02T15:04:05.000000000Z07:00"
+	default:
+		return time.RFC3339
+	}
+}
+
+func parseDurationUnit(unit string) time.Duration {
+	switch strings.ToLower(unit) {
+	case "ns":
+		return time.Nanosecond
+	case "s":
+		return time.Second
 	default:
-		return zerolog.InfoLevel
+		return time.Millisecond
 	}
 }
+
 func logWithFields(level zerolog.Level, message string, fields ...interface{}) {
-	event := log.WithLevel(level)
+	emit(&log.Logger, time.Time{}, level, message, fields, 0)
+}
+
+// logWithFieldsSkip is logWithFields with the caller reported skip frames
+// further up the stack.
+func logWithFieldsSkip(skip int, level zerolog.Level, message string, fields ...interface{}) {
+	emit(&log.Logger, time.Time{}, level, message, fields, skip)
+}
+
+func logWithFieldsAt(t time.Time, level zerolog.Level, message string, fields []interface{}) {
+	emit(&log.Logger, t, level, message, fields, 0)
+}
+
+// logCustom emits without zerolog's level field, adding name in its place,
+// after filtering at the level name was registered with.
+func logCustom(zl *zerolog.Logger, name, message string, fields []interface{}) {
+	level, _ := lookupCustomLevel(name)
+	if currentLevel() > level || zl.GetLevel() > level {
+		return
+	}
+	emit(zl, time.Time{}, zerolog.NoLevel, message, append([]interface{}{zerolog.LevelFieldName, strings.ToLower(name)}, fields...), 0)
+}
+
+// logWithError logs err with its stack under message, or under err.Error()
+// when message is empty.
+func logWithError(level zerolog.Level, message string, err error, fields ...interface{}) {
+	if err == nil {
+		return
+	}
+	if message == "" {
+		message = err.Error()
+	}
+	fields = appendJoined(fields, err)
+	if wantStack(err) {
+		err = errors.WithStack(err)
+	}
+	emit(&log.Logger, time.Time{}, level, message, append(fields, "error", err), 0)
+}
+
+// errorList logs as a JSON array of error messages.
+type errorList []error
 
+func (l errorList) MarshalZerologArray(a *zerolog.Array) {
+	for _, err := range l {
+		a.Str(err.Error())
+	}
+}
+
+// appendJoined adds an "errors" array when err wraps several errors, as
+// errors.Join does, so each keeps its own message.
+func appendJoined(fields []interface{}, err error) []interface{} {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return append(fields, "errors", errorList(joined.Unwrap()))
+	}
+	return fields
+}
+
+// captureStacks is false when Config.CaptureStacks turns stack traces off.
+var captureStacks = true
+
+// recoverLogPanics is false when Config.RecoverFromLogPanics is turned off.
+var recoverLogPanics = true
+
+// noStackError is an error marked by NoStack; it deliberately has no Unwrap
+// so a stack carried by the original error isn't found either.
+type noStackError struct {
+	error
+}
+
+// NoStack marks err so the *WithError helpers log it without a stack trace,
+// e.g. for expected errors such as context.Canceled.
+func NoStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	return noStackError{err}
+}
+
+// stackTracer is implemented by pkg/errors errors that carry a stack.
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+// wantStack reports whether the *WithError helpers should wrap err with a
+// stack. They call errors.WithStack themselves so the trace starts at them.
+// An error that already carries a stack keeps its original, deeper one.
+func wantStack(err error) bool {
+	if _, ok := err.(noStackError); ok || !captureStacks {
+		return false
+	}
+	var st stackTracer
+	return !errors.As(err, &st)
+}
+
+// emit is the single write path shared by the package functions and *Logger,
+// so both sit at the same caller depth. A non-zero at replaces the record's
+// timestamp, and skip moves the caller and func fields that many frames
+// further up the stack.
+func emit(zl *zerolog.Logger, at time.Time, level zerolog.Level, message string, fields []interface{}, skip int) {
+	// Records below the active level return before an event is built, so
+	// they skip the field loop and the caller hook, which only runs inside
+	// Msg. Custom levels log as NoLevel, filtered by logCustom.
+	if level < currentLevel() {
+		return
+	}
+	event := zl.WithLevel(level)
+	if event == nil {
+		return
+	}
+
+	if recoverLogPanics {
+		// A panicking marshaler or writer must not take the caller down
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Fprintf(os.Stderr, "logger: logging panic recovered: %v (message %q)\n", r, message)
+			}
+		}()
+	}
+
+	if !at.IsZero() {
+		event = event.Ctx(withTimestamp(event.GetCtx(), at))
+	}
+	if skip > 0 {
+		event = event.CallerSkipFrame(skip).Ctx(withCallerSkip(event.GetCtx(), skip))
+	}
+	if level == zerolog.NoLevel && len(fields) >= 2 && fields[0] == zerolog.LevelFieldName {
+		// Hooks see a custom level as NoLevel; eventLevelName finds its name here
+		if name, ok := fields[1].(string); ok {
+			event = event.Ctx(withCustomLevel(event.GetCtx(), name))
+		}
+	}
+
+	// Fast path for the common no-field call: field samplers and the field
+	// loop have nothing to look at
+	if len(fields) == 0 && requiredFields == nil {
+		event.Msg(message)
+		return
+	}
+
+	if (valueSampler != nil && !valueSampler.allow(fields)) ||
+		(traceIDSampler != nil && !traceIDSampler.allow(fields)) {
+		event.Discard()
+		droppedBySampling.Add(1)
+		return
+	}
+
+	event = appendFields(event, fields)
+
+	if missing := missingFields(level, fields); len(missing) > 0 {
+		event = event.Strs("missing_required_field", missing)
+	}
+	event.Msg(message)
+}
+
+// appendFields adds key-value pairs to event. It also fills nested objects,
+// so groups follow the same rules as top-level fields.
+func appendFields(event *zerolog.Event, fields []interface{}) *zerolog.Event {
 	if len(fields)%2 != 0 {
 		event = event.Interface("fields_error", "uneven number of key-value pairs")
 	} else {
+	loop:
 		for i := 0; i < len(fields); i += 2 {
 			key, okKey := fields[i].(string)
-			value, okValue := fields[i+1].(string)
-			if okKey && okValue {
-				event = event.Str(key, value)
-			} else {
+			if !okKey {
 				event = event.Interface("fields_error", "key-value pairs must be strings")
 				break
 			}
+			if normalizeKeys {
+				key = snakeCase(key)
+			}
+			value := fields[i+1]
+			if lazy, ok := value.(func() interface{}); ok {
+				// Only called once the record is known to be written
+				value = lazy()
+			}
+			switch value := value.(type) {
+			case string:
+				if masker != nil {
+					if masked, ok := masker(key, value); ok {
+						value = masked
+					}
+				}
+				if hashKeys[key] {
+					value = hashValue(value)
+				}
+				if typ, ok := fieldTypes[key]; ok {
+					event = appendCoerced(event, key, value, typ)
+					continue
+				}
+				event = event.Str(key, value)
+			case time.Duration:
+				// Emitted as a float in the configured DurationUnit
+				event = event.Dur(key, value)
+			case json.RawMessage:
+				// Compacted so a pretty-printed document can't split the record
+				var compact bytes.Buffer
+				if err := json.Compact(&compact, value); err != nil {
+					event = event.Str(key, string(value)).Str("fields_warning", key+" is not valid JSON, logged as a string")
+					continue
+				}
+				event = event.RawJSON(key, compact.Bytes())
+			case []byte:
+				if base64Bytes {
+					event = event.Str(key, base64.StdEncoding.EncodeToString(value))
+				} else {
+					event = event.Hex(key, value)
+				}
+			case anyValue:
+				event = event.Interface(key, value.v)
+			case zerolog.LogObjectMarshaler:
+				event = event.Object(key, value)
+			case zerolog.LogArrayMarshaler:
+				event = event.Array(key, value)
+			case error:
+				if key == zerolog.ErrorFieldName && captureStacks {
+					event = event.Stack().Err(value)
+				} else {
+					event = event.AnErr(key, value)
+				}
+			default:
+				event = event.Interface("fields_error", "key-value pairs must be strings")
+				break loop
+			}
 		}
 	}
-	event.Msg(message)
+	return event
+}
+
+// Log emits at a level chosen at runtime, e.g. one forwarded from another system.
+func Log(level zerolog.Level, message string, fields ...interface{}) {
+	logWithFields(level, message, fields...)
+}
+
+// LogAt emits a record stamped with t instead of the current time, e.g. when
+// replaying historical events.
+func LogAt(t time.Time, level zerolog.Level, message string, fields ...interface{}) {
+	logWithFieldsAt(t, level, message, fields)
 }
 
 func Info(message string, fields ...interface{}) {
@@ -167,22 +1072,166 @@ func Trace(message string, fields ...interface{}) {
 	logWithFields(zerolog.TraceLevel, message, fields...)
 }
 
+// InfoIf logs only when cond is true. A false cond returns before any
+// work, so lazy func() interface{} fields are never called; ordinary
+// arguments are still evaluated by Go before the call.
+func InfoIf(cond bool, message string, fields ...interface{}) {
+	if cond {
+		logWithFields(zerolog.InfoLevel, message, fields...)
+	}
+}
+
+func DebugIf(cond bool, message string, fields ...interface{}) {
+	if cond {
+		logWithFields(zerolog.DebugLevel, message, fields...)
+	}
+}
+
+func WarnIf(cond bool, message string, fields ...interface{}) {
+	if cond {
+		logWithFields(zerolog.WarnLevel, message, fields...)
+	}
+}
+
+func ErrorIf(cond bool, message string, fields ...interface{}) {
+	if cond {
+		logWithFields(zerolog.ErrorLevel, message, fields...)
+	}
+}
+
+func TraceIf(cond bool, message string, fields ...interface{}) {
+	if cond {
+		logWithFields(zerolog.TraceLevel, message, fields...)
+	}
+}
+
+// TimeOp returns a func that, when deferred, logs a Warn line if more than
+// threshold has passed since TimeOp was called:
+//
+//	defer logger.TimeOp("db.query", 200*time.Millisecond)()
+func TimeOp(name string, threshold time.Duration) func() {
+	start := time.Now()
+	return func() {
+		if elapsed := time.Since(start); elapsed > threshold {
+			logWithFields(zerolog.WarnLevel, "slow operation", "op", name, "elapsed", elapsed, "threshold", threshold)
+		}
+	}
+}
+
+func Notice(message string, fields ...interface{}) {
+	logCustom(&log.Logger, LevelNotice, message, fields)
+}
+
+// LogCustom emits at a level added with RegisterLevel. An unregistered name
+// is filtered like Info.
+func LogCustom(level, message string, fields ...interface{}) {
+	logCustom(&log.Logger, level, message, fields)
+}
+
+// TraceEnter logs "enter <name>" at Trace level and returns a func that logs
+// "exit <name>" with the elapsed time, meant to be deferred:
+//
+//	defer logger.TraceEnter("load", "id", id)()
+//
+// Both lines carry the same "call_id". When Trace is disabled nothing is
+// logged and the returned func does nothing.
+func TraceEnter(name string, fields ...interface{}) func() {
+	if currentLevel() > zerolog.TraceLevel || log.Logger.GetLevel() > zerolog.TraceLevel {
+		return func() {}
+	}
+	// Cap the slice so the appends below copy instead of sharing a backing array
+	fields = append(fields[:len(fields):len(fields)], "call_id", randomHex(8))
+	start := time.Now()
+	logWithFields(zerolog.TraceLevel, "enter "+name, fields...)
+	return func() {
+		logWithFields(zerolog.TraceLevel, "exit "+name, append(fields[:len(fields):len(fields)], "elapsed", time.Since(start))...)
+	}
+}
+
 func WarnWithError(err error, fields ...interface{}) {
-	logWithFields(zerolog.WarnLevel, err.Error(), append(fields, "error", errors.WithStack(err))...)
+	logWithError(zerolog.WarnLevel, "", err, fields...)
 }
 
 func ErrorWithError(err error, fields ...interface{}) {
-	logWithFields(zerolog.ErrorLevel, err.Error(), append(fields, "error", errors.WithStack(err))...)
+	logWithError(zerolog.ErrorLevel, "", err, fields...)
 }
 
 func FatalWithError(err error, fields ...interface{}) {
-	logWithFields(zerolog.FatalLevel, err.Error(), append(fields, "error", errors.WithStack(err))...)
+	logWithError(zerolog.FatalLevel, "", err, fields...)
 }
 
 func PanicWithError(err error, fields ...interface{}) {
-	logWithFields(zerolog.PanicLevel, err.Error(), append(fields, "error", errors.WithStack(err))...)
+	logWithError(zerolog.PanicLevel, "", err, fields...)
 }
 
 func TraceWithError(err error, fields ...interface{}) {
-	logWithFields(zerolog.TraceLevel, err.Error(), append(fields, "error", errors.WithStack(err))...)
+	logWithError(zerolog.TraceLevel, "", err, fields...)
+}
+
+// ErrorMsg logs err with its stack under a fixed message instead of
+// err.Error(), so alerting that groups by message sees one group:
+//
+//	logger.FatalMsg("startup failed", err)
+//
+// A nil err logs nothing, as with ErrorWithError.
+func ErrorMsg(message string, err error, fields ...interface{}) {
+	logWithError(zerolog.ErrorLevel, message, err, fields...)
+}
+
+func TraceMsg(message string, err error, fields ...interface{}) {
+	logWithError(zerolog.TraceLevel, message, err, fields...)
+}
+
+func DebugMsg(message string, err error, fields ...interface{}) {
+	logWithError(zerolog.DebugLevel, message, err, fields...)
+}
+
+func InfoMsg(message string, err error, fields ...interface{}) {
+	logWithError(zerolog.InfoLevel, message, err, fields...)
+}
+
+func WarnMsg(message string, err error, fields ...interface{}) {
+	logWithError(zerolog.WarnLevel, message, err, fields...)
+}
+
+func FatalMsg(message string, err error, fields ...interface{}) {
+	logWithError(zerolog.FatalLevel, message, err, fields...)
+}
+
+func PanicMsg(message string, err error, fields ...interface{}) {
+	logWithError(zerolog.PanicLevel, message, err, fields...)
+}
+
+// ErrorReturn logs err with its stack at Error level and returns it unchanged,
+// so call sites can write `return logger.ErrorReturn(err, "op", "save")`.
+// A nil err is returned without logging.
+func ErrorReturn(err error, fields ...interface{}) error {
+	logWithError(zerolog.ErrorLevel, "", err, fields...)
+	return err
+}
+
+// WarnReturn is ErrorReturn at Warn level.
+func WarnReturn(err error, fields ...interface{}) error {
+	logWithError(zerolog.WarnLevel, "", err, fields...)
+	return err
+}
+
+// onceKeys holds the keys WarnOnce has already logged.
+var onceKeys sync.Map
+
+// WarnOnce logs at Warn level only the first time it is called with key, e.g.
+// for a deprecation notice on a hot path.
+func WarnOnce(key, message string, fields ...interface{}) {
+	if _, seen := onceKeys.LoadOrStore(key, struct{}{}); seen {
+		return
+	}
+	logWithFields(zerolog.WarnLevel, message, fields...)
+}
+
+// ResetOnce forgets every key WarnOnce has logged, for tests.
+func ResetOnce() {
+	onceKeys.Range(func(key, _ interface{}) bool {
+		onceKeys.Delete(key)
+		return true
+	})
 }
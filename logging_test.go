@@ -0,0 +1,1068 @@
+// logging_test.go
+
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// initTest rebuilds the package logger from config for one test and closes
+// its outputs when the test ends.
+func initTest(t *testing.T, config Config) {
+	t.Helper()
+	if config.ServiceName == "" {
+		config.ServiceName = "test"
+	}
+	Reinit(config)
+	t.Cleanup(func() { Close() })
+}
+
+// tempLogFile returns a path for a log file removed after the test.
+func tempLogFile(t *testing.T, name string) string {
+	t.Helper()
+	return filepath.Join(t.TempDir(), name)
+}
+
+// readRecords parses every line of path as a JSON record.
+func readRecords(t *testing.T, path string) []map[string]interface{} {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var record map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line is not valid JSON: %v: %q", err, scanner.Text())
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+// messages returns the "message" of each record.
+func messages(records []map[string]interface{}) []string {
+	var msgs []string
+	for _, record := range records {
+		msg, _ := record["message"].(string)
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+// stalledListener accepts connections and never reads from them, so writes
+// block once the socket buffers fill.
+func stalledListener(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var conns []net.Conn
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+	return ln
+}
+
+// recordingWriter keeps everything written to it and counts the Write calls.
+type recordingWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes++
+	return w.buf.Write(p)
+}
+
+func (w *recordingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func (w *recordingWriter) Writes() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writes
+}
+
+// lineListener is a "tcp", "tcp6" or "unix" listener passing every received
+// line to lines.
+func lineListener(t *testing.T, network string) (net.Listener, <-chan string) {
+	t.Helper()
+	address := "127.0.0.1:0"
+	switch network {
+	case "tcp6":
+		address = "[::1]:0"
+	case "unix":
+		address = filepath.Join(t.TempDir(), "sink.sock")
+	}
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := make(chan string, 4096)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				scanner.Buffer(nil, 1<<20)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return ln, lines
+}
+
+// receiveMessages reads n lines from lines and returns their messages.
+func receiveMessages(t *testing.T, lines <-chan string, n int) []string {
+	t.Helper()
+	var msgs []string
+	timeout := time.After(5 * time.Second)
+	for len(msgs) < n {
+		select {
+		case line := <-lines:
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("line is not valid JSON: %v: %q", err, line)
+			}
+			msg, _ := record["message"].(string)
+			msgs = append(msgs, msg)
+		case <-timeout:
+			t.Fatalf("received %d of %d lines: %v", len(msgs), n, msgs)
+		}
+	}
+	return msgs
+}
+
+func TestNoFieldAllocs(t *testing.T) {
+	initTest(t, Config{LogLevel: "Info", LogFilePath: tempLogFile(t, "app.log")})
+
+	// zerolog itself allocates for the caller; the wrappers must add nothing
+	direct := testing.AllocsPerRun(1000, func() { log.Logger.Info().Msg("hello") })
+	if got := testing.AllocsPerRun(1000, func() { Info("hello") }); got > direct {
+		t.Errorf("Info with no fields: %v allocs, zerolog alone %v", got, direct)
+	}
+	if got := testing.AllocsPerRun(1000, func() { Debug("hello") }); got != 0 {
+		t.Errorf("filtered Debug: %v allocs, want 0", got)
+	}
+	if got := testing.AllocsPerRun(1000, func() { Debug("hello", "key", "value") }); got != 0 {
+		t.Errorf("filtered Debug with fields: %v allocs, want 0", got)
+	}
+}
+
+func BenchmarkInfoNoFields(b *testing.B) {
+	Reinit(Config{ServiceName: "bench", LogLevel: "Info", LogFilePath: os.DevNull})
+	defer Close()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Info("hello")
+	}
+}
+
+func BenchmarkInfoFields(b *testing.B) {
+	Reinit(Config{ServiceName: "bench", LogLevel: "Info", LogFilePath: os.DevNull})
+	defer Close()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Info("hello", "path", "/orders", "status", "ok")
+	}
+}
+
+func TestLogstashWriterReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	w, err := NewLogstashWriter("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if !w.Healthy() {
+		t.Fatal("new writer is not healthy")
+	}
+
+	// Take the sink down; a write fails once the peer's reset arrives
+	ln.Close()
+	(<-accepted).Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for w.Healthy() {
+		if time.Now().After(deadline) {
+			t.Fatal("writer stayed healthy with the sink gone")
+		}
+		w.Write([]byte("{}\n"))
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The redial is refused, after which writes fail fast until the backoff
+	// has passed
+	if _, err := w.Write([]byte("{}\n")); err == nil || errors.Is(err, ErrRedialBackoff) {
+		t.Fatalf("first write after the drop: %v, want a dial error", err)
+	}
+	start := time.Now()
+	if _, err := w.Write([]byte("{}\n")); !errors.Is(err, ErrRedialBackoff) {
+		t.Fatalf("write during backoff: %v, want ErrRedialBackoff", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("write during backoff took %v", elapsed)
+	}
+
+	// Bring the sink back on the same address
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %v", addr, err)
+	}
+	defer ln.Close()
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+	time.Sleep(minRedialBackoff)
+	if _, err := w.Write([]byte("{}\n")); err != nil {
+		t.Fatalf("write after the sink returned: %v", err)
+	}
+	if !w.Healthy() {
+		t.Fatal("writer not healthy after reconnecting")
+	}
+}
+
+func TestErrorFileOnlyErrors(t *testing.T) {
+	logPath := tempLogFile(t, "app.log")
+	errorPath := tempLogFile(t, "errors.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: logPath, ErrorFilePath: errorPath})
+
+	Info("request served")
+	Error("request failed")
+	Close()
+
+	if got := messages(readRecords(t, errorPath)); len(got) != 1 || got[0] != "request failed" {
+		t.Errorf("error file has %v, want only the error", got)
+	}
+	got := messages(readRecords(t, logPath))
+	if len(got) < 2 || got[0] != "request served" || got[1] != "request failed" {
+		t.Errorf("log file has %v, want both records", got)
+	}
+}
+
+func TestFlushKeepsLogging(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, FileBufferSize: 64 << 10})
+
+	Info("first")
+	Info("second")
+	if got := messages(readRecords(t, path)); len(got) != 0 {
+		t.Fatalf("file has %v before Flush, want the records still buffered", got)
+	}
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(readRecords(t, path)); len(got) != 2 || got[0] != "first" || got[1] != "second" {
+		t.Fatalf("file has %v after Flush", got)
+	}
+
+	Info("third")
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(readRecords(t, path)); len(got) != 3 || got[2] != "third" {
+		t.Fatalf("file has %v after logging on", got)
+	}
+}
+
+// BenchmarkDebugFiltered measures a Debug call dropped by the Info level,
+// which returns before any event or caller work.
+func BenchmarkDebugFiltered(b *testing.B) {
+	Reinit(Config{ServiceName: "bench", LogLevel: "Info", LogFilePath: os.DevNull})
+	defer Close()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Debug("hello", "path", "/orders")
+	}
+}
+
+// BenchmarkDebugFilteredEagerCaller is the same dropped record with the event
+// and caller built before the level check, for comparison.
+func BenchmarkDebugFilteredEagerCaller(b *testing.B) {
+	Reinit(Config{ServiceName: "bench", LogLevel: "Info", LogFilePath: os.DevNull})
+	defer Close()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		event := log.Logger.Debug().Caller().Str("path", "/orders")
+		if zerolog.DebugLevel < currentLevel() {
+			event.Discard()
+			continue
+		}
+		event.Msg("hello")
+	}
+}
+
+func TestConcurrentFileWrites(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	// Records larger than a pipe buffer would tear without the per-Write lock
+	payload := string(bytes.Repeat([]byte("x"), 8<<10))
+	const goroutines, perGoroutine = 16, 100
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				Info("stress", "payload", payload)
+			}
+		}()
+	}
+	wg.Wait()
+	Close()
+
+	// readRecords fails on any line that isn't valid JSON
+	n := 0
+	for _, msg := range messages(readRecords(t, path)) {
+		if msg == "stress" {
+			n++
+		}
+	}
+	if n != goroutines*perGoroutine {
+		t.Errorf("got %d records, want %d", n, goroutines*perGoroutine)
+	}
+}
+
+// findRecord returns the first record in path with the given message.
+func findRecord(t *testing.T, path, message string) map[string]interface{} {
+	t.Helper()
+	for _, record := range readRecords(t, path) {
+		if record["message"] == message {
+			return record
+		}
+	}
+	t.Fatalf("no %q record in %s", message, path)
+	return nil
+}
+
+func TestDurationUnit(t *testing.T) {
+	for _, tt := range []struct {
+		unit string
+		want float64
+	}{
+		{"", 1500},
+		{"ms", 1500},
+		{"s", 1.5},
+		{"ns", 1.5e9},
+	} {
+		path := tempLogFile(t, "app.log")
+		initTest(t, Config{LogLevel: "Info", LogFilePath: path, DurationUnit: tt.unit})
+		Info("request served", "elapsed", 1500*time.Millisecond)
+		Close()
+
+		if got := findRecord(t, path, "request served")["elapsed"]; got != tt.want {
+			t.Errorf("unit %q: elapsed = %v, want %v", tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestLogAtZerologLevel(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	Log(zerolog.WarnLevel, "forwarded", "source", "upstream")
+	Log(zerolog.DebugLevel, "filtered")
+	Close()
+
+	record := findRecord(t, path, "forwarded")
+	if record["level"] != "warn" || record["source"] != "upstream" {
+		t.Errorf("got %v", record)
+	}
+	for _, msg := range messages(readRecords(t, path)) {
+		if msg == "filtered" {
+			t.Error("Debug record logged at Info level")
+		}
+	}
+}
+
+func TestLogstashCircuitBreaker(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	w, err := NewLogstashWriter("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	const cooldown = 200 * time.Millisecond
+	w.SetCircuitBreaker(2, cooldown)
+
+	// A failed write and a refused redial open the circuit
+	ln.Close()
+	(<-accepted).Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if time.Now().After(deadline) {
+			t.Fatal("circuit never opened")
+		}
+		if _, err := w.Write([]byte("{}\n")); errors.Is(err, ErrCircuitOpen) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %v", addr, err)
+	}
+	defer ln.Close()
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+
+	// Still open until the cooldown has passed, then the probe closes it
+	if _, err := w.Write([]byte("{}\n")); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("write during cooldown: %v, want ErrCircuitOpen", err)
+	}
+	time.Sleep(cooldown)
+	if _, err := w.Write([]byte("{}\n")); err != nil {
+		t.Fatalf("write after the cooldown: %v", err)
+	}
+	if _, err := w.Write([]byte("{}\n")); err != nil {
+		t.Fatalf("write with the circuit closed: %v", err)
+	}
+}
+
+func TestInitWithLogger(t *testing.T) {
+	initTest(t, Config{LogLevel: "Info", LogFilePath: tempLogFile(t, "app.log")})
+	Close()
+
+	var buf recordingWriter
+	InitWithLogger(zerolog.New(&buf).With().Str("team", "payments").Logger())
+	Info("adopted", "order", "o1")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(buf.String()), &record); err != nil {
+		t.Fatalf("%v: %q", err, buf.String())
+	}
+	if record["message"] != "adopted" || record["team"] != "payments" || record["order"] != "o1" {
+		t.Errorf("got %v", record)
+	}
+}
+
+func TestEnvironmentField(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, Environment: "staging"})
+	Info("started")
+	Close()
+
+	if got := findRecord(t, path, "started")["env"]; got != "staging" {
+		t.Errorf("env = %v, want staging", got)
+	}
+	if err := (Config{ServiceName: "test", Console: true, Environment: "qa"}).Validate(); err == nil {
+		t.Error("unknown environment accepted")
+	}
+}
+
+func TestIncludeFieldToggles(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, IncludePID: Bool(false)})
+	Info("started")
+	Close()
+
+	record := findRecord(t, path, "started")
+	if _, ok := record["pid"]; ok {
+		t.Errorf("pid present with IncludePID false: %v", record)
+	}
+	if record["service"] != "test" {
+		t.Errorf("service = %v, want test", record["service"])
+	}
+	if _, ok := record["pod"]; !ok {
+		t.Errorf("pod missing by default: %v", record)
+	}
+}
+
+func TestReinit(t *testing.T) {
+	first := tempLogFile(t, "first.log")
+	initTest(t, Config{ServiceName: "billing", LogLevel: "Info", LogFilePath: first})
+
+	// A plain second InitLogger is ignored
+	InitLogger(Config{ServiceName: "ignored", LogLevel: "Info", LogFilePath: first})
+	Info("before")
+
+	second := tempLogFile(t, "second.log")
+	Reinit(Config{ServiceName: "invoicing", LogLevel: "Info", LogFilePath: second})
+	Info("after")
+	Close()
+
+	if got := findRecord(t, first, "before")["service"]; got != "billing" {
+		t.Errorf("service before Reinit = %v, want billing", got)
+	}
+	if got := findRecord(t, second, "after")["service"]; got != "invoicing" {
+		t.Errorf("service after Reinit = %v, want invoicing", got)
+	}
+	for _, msg := range messages(readRecords(t, first)) {
+		if msg == "after" {
+			t.Error("record after Reinit reached the old file")
+		}
+	}
+}
+
+func TestSyncGlobal(t *testing.T) {
+	initTest(t, Config{LogLevel: "Info", LogFilePath: tempLogFile(t, "app.log")})
+	var sink recordingWriter
+	defer AddOutput(&sink)()
+
+	// A dependency swaps the global logger; SyncGlobal points it back
+	log.Logger = zerolog.New(io.Discard)
+	SyncGlobal()
+	log.Info().Str("component", "dependency").Msg("third party")
+
+	if got := sink.String(); !strings.Contains(got, `"message":"third party"`) || !strings.Contains(got, `"service":"test"`) {
+		t.Errorf("configured outputs got %q", got)
+	}
+}
+
+func TestCaptureStacks(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+	ErrorMsg("with stack", errors.New("boom"))
+	ErrorMsg("marked", NoStack(errors.New("boom")))
+	Close()
+	if _, ok := findRecord(t, path, "with stack")["stack"]; !ok {
+		t.Error("no stack by default")
+	}
+	if _, ok := findRecord(t, path, "marked")["stack"]; ok {
+		t.Error("stack on an error marked NoStack")
+	}
+
+	path = tempLogFile(t, "nostack.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, CaptureStacks: Bool(false)})
+	ErrorMsg("disabled", errors.New("boom"))
+	Close()
+	record := findRecord(t, path, "disabled")
+	if _, ok := record["stack"]; ok {
+		t.Error("stack with CaptureStacks false")
+	}
+	if record["error"] != "boom" {
+		t.Errorf("error = %v, want boom", record["error"])
+	}
+}
+
+func deepFailure() error {
+	return pkgerrors.New("disk full")
+}
+
+func TestExistingStackKept(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+	ErrorMsg("save failed", deepFailure())
+	Close()
+
+	stack, _ := findRecord(t, path, "save failed")["stack"].([]interface{})
+	if len(stack) == 0 {
+		t.Fatal("no stack logged")
+	}
+	if frame, _ := stack[0].(map[string]interface{}); frame["func"] != "deepFailure" {
+		t.Errorf("top frame %v, want the original deepFailure frame", stack[0])
+	}
+}
+
+func TestTraceEnter(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Trace", LogFilePath: path})
+
+	func() {
+		defer TraceEnter("load", "id", "42")()
+		time.Sleep(time.Millisecond)
+	}()
+	Close()
+
+	enter := findRecord(t, path, "enter load")
+	exit := findRecord(t, path, "exit load")
+	if enter["call_id"] == nil || enter["call_id"] != exit["call_id"] {
+		t.Errorf("call_id %v and %v, want the same id", enter["call_id"], exit["call_id"])
+	}
+	if enter["id"] != "42" || exit["id"] != "42" {
+		t.Errorf("fields not on both lines: %v, %v", enter, exit)
+	}
+	if elapsed, _ := exit["elapsed"].(float64); elapsed <= 0 {
+		t.Errorf("elapsed = %v, want positive", exit["elapsed"])
+	}
+
+	path = tempLogFile(t, "info.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+	TraceEnter("load")()
+	Close()
+	if n := countMessages(t, path, "enter load") + countMessages(t, path, "exit load"); n != 0 {
+		t.Errorf("%d trace lines logged at Info", n)
+	}
+}
+
+func TestConnectionStateCallback(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	w, err := NewLogstashWriter("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	states := make(chan bool, 16)
+	w.OnStateChange(func(connected bool) {
+		// Takes the writer's lock, so this deadlocks if called with it held
+		w.SetCircuitBreaker(0, 0)
+		states <- connected
+	})
+
+	ln.Close()
+	(<-accepted).Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(states) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no callback after the sink went away")
+		}
+		w.Write([]byte("{}\n"))
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := <-states; got {
+		t.Fatal("callback got true when the connection dropped")
+	}
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %v", addr, err)
+	}
+	defer ln.Close()
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+	deadline = time.Now().Add(5 * time.Second)
+	for len(states) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no callback after the sink came back")
+		}
+		w.Write([]byte("{}\n"))
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := <-states; !got {
+		t.Fatal("callback got false when the connection recovered")
+	}
+}
+
+func TestWarnOnce(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+	ResetOnce()
+	defer ResetOnce()
+
+	for i := 0; i < 3; i++ {
+		WarnOnce("legacy-flag", "legacy flag is deprecated")
+	}
+	WarnOnce("other-flag", "other flag is deprecated")
+	Close()
+
+	if got := countMessages(t, path, "legacy flag is deprecated"); got != 1 {
+		t.Errorf("logged %d times, want once", got)
+	}
+	if got := countMessages(t, path, "other flag is deprecated"); got != 1 {
+		t.Errorf("other key logged %d times, want once", got)
+	}
+}
+
+func TestTimestampPrecision(t *testing.T) {
+	for _, tt := range []struct {
+		precision string
+		digits    int
+	}{
+		{"", 0},
+		{"seconds", 0},
+		{"millis", 3},
+		{"micros", 6},
+		{"nanos", 9},
+	} {
+		path := tempLogFile(t, "app.log")
+		initTest(t, Config{LogLevel: "Info", LogFilePath: path, TimestampPrecision: tt.precision})
+		Info("stamped")
+		Close()
+
+		ts, _ := findRecord(t, path, "stamped")["time"].(string)
+		digits := 0
+		if i := strings.IndexByte(ts, '.'); i >= 0 {
+			digits = strings.IndexByte(ts, 'Z') - i - 1
+		}
+		if digits != tt.digits || !strings.HasSuffix(ts, "Z") {
+			t.Errorf("precision %q: time %q has %d fractional digits, want %d", tt.precision, ts, digits, tt.digits)
+		}
+	}
+}
+
+func TestJoinedErrors(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	ErrorWithError(errors.Join(errors.New("disk full"), errors.New("quota exceeded")))
+	Close()
+
+	record := findRecord(t, path, "disk full\nquota exceeded")
+	list, _ := record["errors"].([]interface{})
+	if len(list) != 2 || list[0] != "disk full" || list[1] != "quota exceeded" {
+		t.Fatalf("errors = %v", record["errors"])
+	}
+}
+
+func TestLazyField(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	called := false
+	snapshot := func() interface{} {
+		called = true
+		return "cache snapshot"
+	}
+	Debug("cache state", "snapshot", snapshot)
+	if called {
+		t.Fatal("lazy field evaluated for a filtered Debug")
+	}
+
+	Info("cache state", "snapshot", snapshot)
+	Close()
+	if !called {
+		t.Fatal("lazy field not evaluated for Info")
+	}
+	if got := findRecord(t, path, "cache state")["snapshot"]; got != "cache snapshot" {
+		t.Errorf("snapshot = %v", got)
+	}
+}
+
+func TestLogstashUnixSocket(t *testing.T) {
+	ln, lines := lineListener(t, "unix")
+	initTest(t, Config{
+		LogLevel:           "Info",
+		LogAnalyserAddress: ln.Addr().String(),
+		LogAnalyserNetwork: "unix",
+		LogAnalyserEnabled: true,
+	})
+
+	Info("over the socket")
+	Flush()
+	if got := receiveMessages(t, lines, 1); got[0] != "over the socket" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestLogstashUDP(t *testing.T) {
+	conn, next := udpListener(t)
+	initTest(t, Config{
+		LogLevel:           "Info",
+		LogAnalyserAddress: conn.LocalAddr().String(),
+		LogAnalyserNetwork: "udp",
+		LogAnalyserEnabled: true,
+	})
+
+	Info("fire and forget", "request_id", "r1")
+	Flush()
+	for {
+		datagram := next()
+		if !bytes.HasSuffix(datagram, []byte("\n")) || bytes.Count(datagram, []byte("\n")) != 1 {
+			t.Fatalf("datagram %q is not one newline-terminated record", datagram)
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal(datagram, &record); err != nil {
+			t.Fatalf("datagram %q: %v", datagram, err)
+		}
+		if record["message"] == "fire and forget" {
+			if record["request_id"] != "r1" {
+				t.Errorf("got %v", record)
+			}
+			return
+		}
+	}
+}
+
+func TestTimeOp(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	TimeOp("fast", time.Hour)()
+	done := TimeOp("slow", time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	done()
+	Close()
+
+	var slow []map[string]interface{}
+	for _, record := range readRecords(t, path) {
+		if record["message"] == "slow operation" {
+			slow = append(slow, record)
+		}
+	}
+	if len(slow) != 1 {
+		t.Fatalf("%d slow operation records, want 1", len(slow))
+	}
+	if slow[0]["op"] != "slow" || slow[0]["level"] != "warn" {
+		t.Errorf("got %v", slow[0])
+	}
+	if elapsed, _ := slow[0]["elapsed"].(float64); elapsed < 10 {
+		t.Errorf("elapsed = %v, want at least 10ms", slow[0]["elapsed"])
+	}
+}
+
+func TestInfoIf(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	calls := 0
+	snapshot := func() interface{} {
+		calls++
+		return "cache snapshot"
+	}
+	InfoIf(false, "migrating", "tenant", "t1", "snapshot", snapshot)
+	if calls != 0 {
+		t.Fatal("lazy field evaluated for a false condition")
+	}
+	InfoIf(true, "migrating", "tenant", "t2", "snapshot", snapshot)
+	Close()
+
+	if calls != 1 {
+		t.Errorf("lazy field evaluated %d times, want once", calls)
+	}
+	if n := countMessages(t, path, "migrating"); n != 1 {
+		t.Fatalf("%d records, want 1", n)
+	}
+	if got := findRecord(t, path, "migrating")["tenant"]; got != "t2" {
+		t.Errorf("tenant = %v", got)
+	}
+}
+
+func TestErrorMsg(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	ErrorMsg("startup failed", errors.New("bind: address already in use"), "port", "8080")
+	// Like FatalWithError, FatalMsg logs through WithLevel and doesn't exit
+	FatalMsg("invariant broken", errors.New("negative balance"))
+	Close()
+
+	record := findRecord(t, path, "startup failed")
+	if record["error"] != "bind: address already in use" || record["port"] != "8080" || record["level"] != "error" {
+		t.Errorf("got %v", record)
+	}
+	if got := findRecord(t, path, "invariant broken"); got["error"] != "negative balance" || got["level"] != "fatal" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestCurrentConfig(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{
+		ServiceName: "billing",
+		LogLevel:    "Warn",
+		LogFilePath: path,
+		SampleRate:  10,
+		HashFields:  []string{"user_id"},
+	})
+
+	got := CurrentConfig()
+	if got.ServiceName != "billing" || got.LogFilePath != path || got.SampleRate != 10 {
+		t.Errorf("got %+v", got)
+	}
+	if !reflect.DeepEqual(got.HashFields, []string{"user_id"}) {
+		t.Errorf("HashFields = %v", got.HashFields)
+	}
+	if got.LogLevel != "warn" || got.BodyLogLimit != defaultBodyLogLimit || got.LevelWarmupRate != defaultLevelWarmupRate {
+		t.Errorf("LogLevel %q, BodyLogLimit %d, LevelWarmupRate %d, want the values in effect",
+			got.LogLevel, got.BodyLogLimit, got.LevelWarmupRate)
+	}
+}
+
+type panickingObject struct{}
+
+func (panickingObject) MarshalZerologObject(*zerolog.Event) {
+	panic("marshal failed")
+}
+
+func TestRecoverFromLogPanics(t *testing.T) {
+	stderr := swapPipe(t, &os.Stderr)
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	Info("snapshot", "state", panickingObject{})
+	Info("still logging")
+	Close()
+
+	if out := stderr(); !strings.Contains(out, "logging panic recovered: marshal failed") || !strings.Contains(out, `"snapshot"`) {
+		t.Errorf("stderr = %q", out)
+	}
+	findRecord(t, path, "still logging")
+
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, RecoverFromLogPanics: Bool(false)})
+	defer func() {
+		if recover() == nil {
+			t.Error("panic recovered with RecoverFromLogPanics false")
+		}
+	}()
+	Info("snapshot", "state", panickingObject{})
+}
+
+func TestRawJSONField(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	Info("upstream response", "body", json.RawMessage("{\n  \"status\": \"ok\",\n  \"items\": [1, 2]\n}"))
+	Info("bad response", "body", json.RawMessage(`{"status":`))
+	Close()
+
+	want := map[string]interface{}{"status": "ok", "items": []interface{}{1.0, 2.0}}
+	if got := findRecord(t, path, "upstream response")["body"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("body = %#v, want it nested as %v", got, want)
+	}
+	bad := findRecord(t, path, "bad response")
+	if bad["body"] != `{"status":` || bad["fields_warning"] == nil {
+		t.Errorf("got %v, want the invalid JSON as a string with a warning", bad)
+	}
+}
+
+func TestErrorReturn(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	err := errors.New("connection refused")
+	if got := ErrorReturn(err, "host", "db1"); got != err {
+		t.Errorf("ErrorReturn returned %v, want the same error", got)
+	}
+	if got := ErrorReturn(nil); got != nil {
+		t.Errorf("ErrorReturn(nil) = %v", got)
+	}
+	Close()
+
+	if n := countMessages(t, path, "connection refused"); n != 1 {
+		t.Fatalf("%d records, want 1", n)
+	}
+	if record := findRecord(t, path, "connection refused"); record["host"] != "db1" || record["level"] != "error" {
+		t.Errorf("got %v", record)
+	}
+}
+
+func TestNewLogstashWriterAddresses(t *testing.T) {
+	ln, lines := lineListener(t, "tcp")
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	w, err := NewLogstashWriter("tcp", net.JoinHostPort("localhost", port))
+	if err != nil {
+		t.Fatalf("hostname: %v", err)
+	}
+	w.Write([]byte(`{"message":"by hostname"}` + "\n"))
+	w.Close()
+	if got := receiveMessages(t, lines, 1); got[0] != "by hostname" {
+		t.Errorf("got %v", got)
+	}
+
+	if probe, err := net.Listen("tcp6", "[::1]:0"); err != nil {
+		t.Logf("skipping the IPv6 literal: %v", err)
+	} else {
+		probe.Close()
+		ln6, lines6 := lineListener(t, "tcp6")
+		w, err := NewLogstashWriter("tcp", ln6.Addr().String())
+		if err != nil {
+			t.Fatalf("IPv6 literal %s: %v", ln6.Addr(), err)
+		}
+		w.Write([]byte(`{"message":"over IPv6"}` + "\n"))
+		w.Close()
+		if got := receiveMessages(t, lines6, 1); got[0] != "over IPv6" {
+			t.Errorf("got %v", got)
+		}
+	}
+
+	for address, want := range map[string]string{
+		"::1:5000":  "must be bracketed",
+		"logstash":  "not host:port",
+		"logstash:": "has no port",
+	} {
+		if _, err := NewLogstashWriter("tcp", address); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: got %v, want an error containing %q", address, err, want)
+		}
+	}
+}
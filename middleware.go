@@ -0,0 +1,100 @@
+// middleware.go
+
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const traceparentHeader = "traceparent"
+
+type traceKey struct{}
+
+// traceContext is the W3C trace context of the request being served. SpanID
+// is this service's span, which downstream calls receive as their parent.
+type traceContext struct {
+	TraceID string
+	SpanID  string
+	Flags   string
+}
+
+// Middleware binds a request logger carrying trace_id and span_id to each
+// request's context, retrievable with FromContext. The trace id comes from an
+// incoming W3C traceparent header; a missing or malformed header starts a new
+// trace. The response carries the traceparent for this hop.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tc, ok := parseTraceparent(r.Header.Get(traceparentHeader))
+		if !ok {
+			tc = traceContext{TraceID: randomHex(16), Flags: "01"}
+		}
+		tc.SpanID = randomHex(8)
+
+		ctx := context.WithValue(r.Context(), traceKey{}, tc)
+		ctx = NewContext(ctx, FromContext(ctx).With("trace_id", tc.TraceID, "span_id", tc.SpanID))
+
+		w.Header().Set(traceparentHeader, tc.traceparent())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// InjectTraceparent sets the traceparent header on an outgoing request so the
+// downstream service joins the trace carried by ctx.
+func InjectTraceparent(ctx context.Context, req *http.Request) {
+	if tc, ok := ctx.Value(traceKey{}).(traceContext); ok {
+		req.Header.Set(traceparentHeader, tc.traceparent())
+	}
+}
+
+// TraceIDs returns the trace and span id Middleware stored in ctx.
+func TraceIDs(ctx context.Context) (traceID, spanID string, ok bool) {
+	tc, ok := ctx.Value(traceKey{}).(traceContext)
+	return tc.TraceID, tc.SpanID, ok
+}
+
+func (tc traceContext) traceparent() string {
+	return "00-" + tc.TraceID + "-" + tc.SpanID + "-" + tc.Flags
+}
+
+// parseTraceparent parses "version-traceid-parentid-flags". Version ff and
+// all-zero ids are invalid per the W3C spec.
+func parseTraceparent(header string) (traceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return traceContext{}, false
+	}
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) ||
+		!isHex(traceID, 32) || isZero(traceID) ||
+		!isHex(parentID, 16) || isZero(parentID) ||
+		!isHex(flags, 2) {
+		return traceContext{}, false
+	}
+	return traceContext{TraceID: traceID, Flags: flags}, true
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,61 @@
+// middleware_test.go
+
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddlewareTraceparent(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	for _, tt := range []struct {
+		name        string
+		header      string
+		sameTraceID bool
+	}{
+		{"valid", "00-" + traceID + "-00f067aa0ba902b7-01", true},
+		{"missing", "", false},
+		{"malformed", "00-" + traceID + "-00f067aa0ba902b7", false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+	} {
+		var gotTrace, gotSpan string
+		handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotTrace, gotSpan, _ = TraceIDs(r.Context())
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		if tt.header != "" {
+			req.Header.Set(traceparentHeader, tt.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if !isHex(gotTrace, 32) || isZero(gotTrace) || !isHex(gotSpan, 16) {
+			t.Errorf("%s: trace %q span %q", tt.name, gotTrace, gotSpan)
+		}
+		if (gotTrace == traceID) != tt.sameTraceID {
+			t.Errorf("%s: trace id %q, want the incoming one: %v", tt.name, gotTrace, tt.sameTraceID)
+		}
+		if want := "00-" + gotTrace + "-" + gotSpan + "-01"; rec.Header().Get(traceparentHeader) != want {
+			t.Errorf("%s: response traceparent %q, want %q", tt.name, rec.Header().Get(traceparentHeader), want)
+		}
+	}
+}
+
+func TestMiddlewareBindsLogger(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	var traceID string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceID, _, _ = TraceIDs(r.Context())
+		FromContext(r.Context()).Info("handled")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	Close()
+
+	if got := findRecord(t, path, "handled")["trace_id"]; got != traceID {
+		t.Errorf("trace_id = %v, want %v", got, traceID)
+	}
+}
@@ -0,0 +1,146 @@
+//go:build otlp
+
+// otlp.go
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+func init() {
+	newOTLPWriter = func(endpoint, protocol string, insecure bool) (io.WriteCloser, error) {
+		return NewOTLPWriter(endpoint, protocol, insecure)
+	}
+}
+
+// OTLPWriter maps each JSON record to an OpenTelemetry log record and exports
+// it through a batching OTLP exporter over gRPC or HTTP.
+type OTLPWriter struct {
+	provider *sdklog.LoggerProvider
+	logger   otellog.Logger
+}
+
+func NewOTLPWriter(endpoint, protocol string, insecure bool) (*OTLPWriter, error) {
+	var exporter sdklog.Exporter
+	var err error
+	switch strings.ToLower(protocol) {
+	case "http":
+		opts := []otlploghttp.Option{otlploghttp.WithEndpoint(endpoint)}
+		if insecure {
+			opts = append(opts, otlploghttp.WithInsecure())
+		}
+		exporter, err = otlploghttp.New(context.Background(), opts...)
+	default:
+		opts := []otlploggrpc.Option{otlploggrpc.WithEndpoint(endpoint)}
+		if insecure {
+			opts = append(opts, otlploggrpc.WithInsecure())
+		}
+		exporter, err = otlploggrpc.New(context.Background(), opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)))
+	return &OTLPWriter{provider: provider, logger: provider.Logger("github.com/practionnetworks/logger")}, nil
+}
+
+func (w *OTLPWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (w *OTLPWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(p, &fields); err != nil {
+		return 0, err
+	}
+
+	level, name := recordLevel(level, fields[zerolog.LevelFieldName])
+
+	var record otellog.Record
+	record.SetSeverity(otlpSeverity(level, name))
+	record.SetSeverityText(name)
+	record.SetObservedTimestamp(time.Now())
+	for key, value := range fields {
+		switch key {
+		case zerolog.MessageFieldName:
+			record.SetBody(otellog.StringValue(toString(value)))
+		case zerolog.TimestampFieldName:
+			if t, err := time.Parse(zerolog.TimeFieldFormat, toString(value)); err == nil {
+				record.SetTimestamp(t)
+			}
+		case zerolog.LevelFieldName:
+		default:
+			record.AddAttributes(otlpAttribute(key, value))
+		}
+	}
+
+	w.logger.Emit(context.Background(), record)
+	return len(p), nil
+}
+
+func (w *OTLPWriter) Flush() error {
+	return w.provider.ForceFlush(context.Background())
+}
+
+func (w *OTLPWriter) Close() error {
+	return w.provider.Shutdown(context.Background())
+}
+
+// otlpSeverity maps a level to an OpenTelemetry severity; notice gets INFO2,
+// as OpenTelemetry maps syslog's Notice.
+func otlpSeverity(level zerolog.Level, name string) otellog.Severity {
+	if name == LevelNotice {
+		return otellog.SeverityInfo2
+	}
+	switch level {
+	case zerolog.TraceLevel:
+		return otellog.SeverityTrace
+	case zerolog.DebugLevel:
+		return otellog.SeverityDebug
+	case zerolog.InfoLevel:
+		return otellog.SeverityInfo
+	case zerolog.WarnLevel:
+		return otellog.SeverityWarn
+	case zerolog.ErrorLevel:
+		return otellog.SeverityError
+	case zerolog.FatalLevel:
+		return otellog.SeverityFatal
+	case zerolog.PanicLevel:
+		return otellog.SeverityFatal4
+	default:
+		return otellog.SeverityUndefined
+	}
+}
+
+func otlpAttribute(key string, value interface{}) otellog.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return otellog.String(key, v)
+	case float64:
+		return otellog.Float64(key, v)
+	case bool:
+		return otellog.Bool(key, v)
+	default:
+		return otellog.String(key, toString(v))
+	}
+}
+
+func toString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(value)
+	return string(b)
+}
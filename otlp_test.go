@@ -0,0 +1,114 @@
+//go:build otlp
+
+// otlp_test.go
+
+package logger
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	otellog "go.opentelemetry.io/otel/log"
+	collogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	otlpcommon "go.opentelemetry.io/proto/otlp/common/v1"
+	otlplogs "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOTLPSeverity(t *testing.T) {
+	for _, tt := range []struct {
+		level zerolog.Level
+		field string
+		want  otellog.Severity
+	}{
+		{zerolog.NoLevel, "notice", otellog.SeverityInfo2},
+		{zerolog.InfoLevel, "info", otellog.SeverityInfo},
+		{zerolog.NoLevel, "warn", otellog.SeverityWarn},
+	} {
+		level, name := recordLevel(tt.level, tt.field)
+		if got := otlpSeverity(level, name); got != tt.want {
+			t.Errorf("%v/%q: got %v, want %v", tt.level, tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestOTLPWriterExport(t *testing.T) {
+	received := make(chan *collogs.ExportLogsServiceRequest, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req := new(collogs.ExportLogsServiceRequest)
+		if err := proto.Unmarshal(body, req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received <- req
+		resp, _ := proto.Marshal(&collogs.ExportLogsServiceResponse{})
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Write(resp)
+	}))
+	defer server.Close()
+
+	w, err := NewOTLPWriter(strings.TrimPrefix(server.URL, "http://"), "http", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.WriteLevel(zerolog.WarnLevel, []byte(`{"level":"warn","message":"disk nearly full","volume":"/data","free_mb":12,"time":"2024-06-01T10:00:00Z"}`))
+	w.Write([]byte(`{"level":"notice","message":"config reloaded"}`))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var records []*otlplogs.LogRecord
+	for len(records) < 2 {
+		select {
+		case req := <-received:
+			for _, rl := range req.ResourceLogs {
+				for _, sl := range rl.ScopeLogs {
+					records = append(records, sl.LogRecords...)
+				}
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of 2 records", len(records))
+		}
+	}
+
+	warn := records[0]
+	if warn.SeverityNumber != otlplogs.SeverityNumber_SEVERITY_NUMBER_WARN || warn.SeverityText != "warn" {
+		t.Errorf("severity %v %q, want WARN", warn.SeverityNumber, warn.SeverityText)
+	}
+	if got := warn.Body.GetStringValue(); got != "disk nearly full" {
+		t.Errorf("body = %q", got)
+	}
+	if want := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC); warn.TimeUnixNano != uint64(want.UnixNano()) {
+		t.Errorf("timestamp = %d, want %v", warn.TimeUnixNano, want)
+	}
+	attrs := make(map[string]*otlpcommon.AnyValue)
+	for _, kv := range warn.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	if got := attrs["volume"].GetStringValue(); got != "/data" {
+		t.Errorf("volume = %q", got)
+	}
+	if got := attrs["free_mb"].GetDoubleValue(); got != 12 {
+		t.Errorf("free_mb = %v", got)
+	}
+	for _, key := range []string{"level", "message", "time"} {
+		if _, ok := attrs[key]; ok {
+			t.Errorf("%q sent as an attribute", key)
+		}
+	}
+
+	if notice := records[1]; notice.SeverityNumber != otlplogs.SeverityNumber_SEVERITY_NUMBER_INFO2 || notice.SeverityText != "notice" {
+		t.Errorf("notice severity %v %q, want INFO2", notice.SeverityNumber, notice.SeverityText)
+	}
+}
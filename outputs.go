@@ -0,0 +1,128 @@
+// outputs.go
+
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var extraFiles []*reopenFile
+
+var otlpWriter io.WriteCloser
+
+// newOTLPWriter is set by otlp.go, which is only built with the otlp build tag
+// so the OpenTelemetry dependencies stay optional.
+var newOTLPWriter func(endpoint, protocol string, insecure bool) (io.WriteCloser, error)
+
+var protoWriter io.WriteCloser
+
+// newProtoWriter is set by SetProtoStream in proto.go, which is only built
+// with the proto build tag so the gRPC dependencies stay optional.
+var newProtoWriter func(address string) (io.WriteCloser, error)
+
+var journaldWriter io.WriteCloser
+
+// newJournaldWriter is set by journald.go, which is only built on Linux.
+var newJournaldWriter func() (io.WriteCloser, error)
+
+// expandPath replaces strftime-style date tokens in a log file path, e.g.
+// "app-%Y-%m-%d.log" becomes "app-2024-06-01.log". Supported tokens are %Y
+// (year), %m (month), %d (day), %H (hour), %M (minute), %S (second) and %%.
+func expandPath(path string, t time.Time) string {
+	if !strings.Contains(path, "%") {
+		return path
+	}
+	return strings.NewReplacer(
+		"%Y", t.Format("2006"),
+		"%m", t.Format("01"),
+		"%d", t.Format("02"),
+		"%H", t.Format("15"),
+		"%M", t.Format("04"),
+		"%S", t.Format("05"),
+		"%%", "%",
+	).Replace(path)
+}
+
+func openLogFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+}
+
+// newOutputWriter opens spec.Path and wraps it to render records in
+// spec.Format, dropping anything below spec.Level. The CEF header and
+// Compact, which replaces the JSON format, come from config.
+func newOutputWriter(spec OutputSpec, config Config) (io.Writer, error) {
+	file, err := newReopenFile(spec.Path, 0)
+	if err != nil {
+		return nil, err
+	}
+	extraFiles = append(extraFiles, file)
+
+	var w io.Writer = file
+	switch strings.ToLower(spec.Format) {
+	case "console":
+		w = newConsoleWriter(w, true)
+	case "logfmt":
+		w = logfmtWriter{w: w}
+	case "pretty":
+		w = prettyWriter{w: w}
+	case "cef":
+		product := config.CEFProduct
+		if product == "" {
+			product = config.ServiceName
+		}
+		w = CEFWriter{W: w, Vendor: config.CEFVendor, Product: product, Version: config.CEFVersion}
+	default:
+		if config.Compact {
+			w = newCompactWriter(w)
+		}
+	}
+
+	level := zerolog.TraceLevel
+	if spec.Level != "" {
+		level = parseLogLevel(spec.Level)
+	}
+	return newLevelFilterWriter(w, level), nil
+}
+
+var newlineEscaper = strings.NewReplacer("\r", `\r`, "\n", `\n`)
+
+// newConsoleWriter renders records for humans, one line each: newlines in the
+// message are escaped, and zerolog already quotes string fields holding them.
+func newConsoleWriter(out io.Writer, noColor bool) zerolog.ConsoleWriter {
+	return zerolog.ConsoleWriter{
+		Out:        out,
+		NoColor:    noColor,
+		TimeFormat: time.RFC3339,
+		// Matches the UTC JSON records unless ConsoleLocalTime is set
+		TimeLocation: time.UTC,
+		FormatMessage: func(i interface{}) string {
+			if i == nil {
+				return ""
+			}
+			return newlineEscaper.Replace(fmt.Sprint(i))
+		},
+	}
+}
+
+// fileOutput is an open file output that Flush syncs and Close closes.
+type fileOutput interface {
+	Sync() error
+	Close() error
+}
+
+// openFiles returns every log file opened by InitLogger.
+func openFiles() []fileOutput {
+	var files []fileOutput
+	for _, file := range append([]*reopenFile{logFile, errorFile, auditFile}, extraFiles...) {
+		if file != nil {
+			files = append(files, file)
+		}
+	}
+	return files
+}
@@ -0,0 +1,177 @@
+// outputs_test.go
+
+package logger
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestConsoleLocalTime(t *testing.T) {
+	prevLocal, prevStdout := time.Local, os.Stdout
+	time.Local = time.FixedZone("UTC+5", 5*60*60)
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	defer func() {
+		time.Local, os.Stdout = prevLocal, prevStdout
+	}()
+
+	logPath := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", Console: true, ConsoleLocalTime: true, LogFilePath: logPath})
+	Info("hello")
+	Close()
+	w.Close()
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(line, "+05:00") {
+		t.Errorf("console line %q lacks the local offset", line)
+	}
+
+	records := readRecords(t, logPath)
+	if ts, _ := records[0]["time"].(string); !strings.HasSuffix(ts, "Z") {
+		t.Errorf("JSON time %q is not UTC", ts)
+	}
+
+	// Other zerolog loggers in the process keep local time
+	if loc := zerolog.TimestampFunc().Location(); loc != time.Local {
+		t.Errorf("zerolog.TimestampFunc returns %v times", loc)
+	}
+}
+
+// swapPipe replaces *file with a pipe until the test ends. The returned func
+// closes the write end and returns everything written.
+func swapPipe(t *testing.T, file **os.File) func() string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := *file
+	*file = w
+	t.Cleanup(func() { *file = prev })
+	return func() string {
+		w.Close()
+		defer r.Close()
+		out, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(out)
+	}
+}
+
+func TestConsoleStderr(t *testing.T) {
+	stdout := swapPipe(t, &os.Stdout)
+	stderr := swapPipe(t, &os.Stderr)
+
+	initTest(t, Config{LogLevel: "Info", Console: true, ConsoleStream: "stderr"})
+	Info("diagnostics")
+	Close()
+
+	if got := stderr(); !strings.Contains(got, "diagnostics") {
+		t.Errorf("stderr got %q", got)
+	}
+	if got := stdout(); strings.Contains(got, "diagnostics") {
+		t.Errorf("stdout got %q", got)
+	}
+}
+
+func TestExtraOutputs(t *testing.T) {
+	jsonPath := tempLogFile(t, "app.json")
+	consolePath := tempLogFile(t, "oncall.log")
+	initTest(t, Config{
+		LogLevel: "Info",
+		ExtraOutputs: []OutputSpec{
+			{Path: jsonPath, Format: "json"},
+			{Path: consolePath, Format: "console", Level: "warn"},
+		},
+	})
+
+	Info("cache warmed")
+	Warn("disk nearly full", "volume", "/data")
+	Close()
+
+	if got := findRecord(t, jsonPath, "disk nearly full"); got["volume"] != "/data" || got["level"] != "warn" {
+		t.Errorf("JSON output got %v", got)
+	}
+	findRecord(t, jsonPath, "cache warmed")
+
+	out, err := os.ReadFile(consolePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	console := string(out)
+	if !strings.Contains(console, "WRN") || !strings.Contains(console, "> disk nearly full") || !strings.Contains(console, "volume=/data") {
+		t.Errorf("console output %q lacks the rendered warning", console)
+	}
+	if strings.Contains(console, "cache warmed") || strings.HasPrefix(console, "{") {
+		t.Errorf("console output %q has the Info record or raw JSON", console)
+	}
+}
+
+func TestDatedLogFilePath(t *testing.T) {
+	at := time.Date(2024, 6, 1, 9, 5, 7, 0, time.UTC)
+	if got := expandPath("app-%Y-%m-%d_%H%M%S-100%%.log", at); got != "app-2024-06-01_090507-100%.log" {
+		t.Errorf("expandPath = %q", got)
+	}
+
+	dir := t.TempDir()
+	initTest(t, Config{LogLevel: "Info", LogFilePath: filepath.Join(dir, "app-%Y-%m-%d.log")})
+	Info("dated")
+	Close()
+
+	want := filepath.Join(dir, "app-"+time.Now().Format("2006-01-02")+".log")
+	findRecord(t, want, "dated")
+}
+
+func TestConsoleFieldOrder(t *testing.T) {
+	stdout := swapPipe(t, &os.Stdout)
+	initTest(t, Config{LogLevel: "Info", Console: true, ConsoleFieldOrder: []string{"request_id", "zeta"}})
+	Info("ordered", "alpha", "a", "zeta", "z", "request_id", "r1")
+	Close()
+
+	out := stdout()
+	line := out[:strings.IndexByte(out, '\n')+1]
+	requestID, zeta, alpha := strings.Index(line, "request_id="), strings.Index(line, "zeta="), strings.Index(line, "alpha=")
+	if requestID < 0 || zeta < 0 || alpha < 0 || !(requestID < zeta && zeta < alpha) {
+		t.Errorf("console line %q, want request_id then zeta before the other fields", line)
+	}
+}
+
+func TestCustomLevelFiltered(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	errorPath := tempLogFile(t, "err.log")
+	warnPath := tempLogFile(t, "warn.log")
+	initTest(t, Config{
+		LogLevel:      "Info",
+		LogFilePath:   path,
+		ErrorFilePath: errorPath,
+		ExtraOutputs:  []OutputSpec{{Path: warnPath, Level: "warn"}},
+	})
+	RegisterLevel("alert", zerolog.ErrorLevel)
+
+	Notice("maintenance window")
+	LogCustom("alert", "replica lagging")
+	Close()
+
+	findRecord(t, path, "maintenance window")
+	for _, p := range []string{errorPath, warnPath} {
+		if n := countMessages(t, p, "maintenance window"); n != 0 {
+			t.Errorf("%s has the notice record", filepath.Base(p))
+		}
+		findRecord(t, p, "replica lagging")
+	}
+}
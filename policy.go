@@ -0,0 +1,40 @@
+// policy.go
+
+package logger
+
+import (
+	"github.com/rs/zerolog"
+)
+
+var requiredFields map[zerolog.Level][]string
+
+func parseRequiredFields(byLevel map[string][]string) map[zerolog.Level][]string {
+	if len(byLevel) == 0 {
+		return nil
+	}
+	required := make(map[zerolog.Level][]string, len(byLevel))
+	for level, keys := range byLevel {
+		required[parseLogLevel(level)] = keys
+	}
+	return required
+}
+
+// missingFields returns the keys required at level that fields doesn't carry.
+func missingFields(level zerolog.Level, fields []interface{}) []string {
+	var missing []string
+	for _, key := range requiredFields[level] {
+		if !hasField(fields, key) {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+func hasField(fields []interface{}, key string) bool {
+	for i := 0; i < len(fields); i += 2 {
+		if k, ok := fields[i].(string); ok && k == key {
+			return true
+		}
+	}
+	return false
+}
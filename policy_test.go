@@ -0,0 +1,47 @@
+// policy_test.go
+
+package logger
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateRequiredFieldsLevel(t *testing.T) {
+	config := Config{ServiceName: "test", Console: true, RequiredFields: map[string][]string{"eror": {"request_id"}}}
+	if err := config.Validate(); err == nil {
+		t.Fatal("unknown RequiredFields level accepted")
+	}
+	config.RequiredFields = map[string][]string{"error": {"request_id"}}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequiredFieldsMarker(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, RequiredFields: map[string][]string{"error": {"request_id"}}})
+
+	Error("no request id")
+	Error("with request id", "request_id", "r1")
+	Info("not checked")
+	Close()
+
+	byMessage := make(map[string]map[string]interface{})
+	for _, record := range readRecords(t, path) {
+		msg, _ := record["message"].(string)
+		byMessage[msg] = record
+	}
+	if got := byMessage["no request id"]["missing_required_field"]; !reflect.DeepEqual(got, []interface{}{"request_id"}) {
+		t.Errorf("missing_required_field = %v", got)
+	}
+	for _, msg := range []string{"with request id", "not checked"} {
+		record, ok := byMessage[msg]
+		if !ok {
+			t.Fatalf("%q not logged", msg)
+		}
+		if _, ok := record["missing_required_field"]; ok {
+			t.Errorf("%q flagged: %v", msg, record)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+// pretty.go
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// prettyWriter re-indents each JSON record across several lines, for local
+// files read by eye. Network outputs keep compact NDJSON.
+type prettyWriter struct {
+	w io.Writer
+}
+
+func (p prettyWriter) Write(b []byte) (int, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, bytes.TrimRight(b, "\n"), "", "  "); err != nil {
+		return p.w.Write(b)
+	}
+	buf.WriteByte('\n')
+	if _, err := p.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
@@ -0,0 +1,38 @@
+// pretty_test.go
+
+package logger
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPrettyJSONFileOnly(t *testing.T) {
+	ln, lines := lineListener(t, "tcp")
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{
+		LogLevel:           "Info",
+		LogFilePath:        path,
+		PrettyJSON:         true,
+		LogAnalyserAddress: ln.Addr().String(),
+		LogAnalyserEnabled: true,
+	})
+	Info("indented", "order", "o1")
+	Flush()
+
+	out, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "\n  \"message\": \"indented\"") {
+		t.Errorf("file output is not indented: %q", out)
+	}
+
+	// receiveMessages parses each received line as a whole record
+	for {
+		if msg := receiveMessages(t, lines, 1)[0]; msg == "indented" {
+			break
+		}
+	}
+}
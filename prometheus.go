@@ -0,0 +1,35 @@
+//go:build prometheus
+
+// prometheus.go
+
+package logger
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// PrometheusHook counts emitted records by level, custom levels such as notice
+// by their name. Pass it in Config.Hooks and register its Collectors with a
+// prometheus.Registerer.
+type PrometheusHook struct {
+	lines *prometheus.CounterVec
+}
+
+func NewPrometheusHook(namespace string) *PrometheusHook {
+	return &PrometheusHook{
+		lines: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "log_lines_total",
+			Help:      "Log records emitted, by level.",
+		}, []string{"level"}),
+	}
+}
+
+func (h *PrometheusHook) Run(e *zerolog.Event, level zerolog.Level, message string) {
+	h.lines.WithLabelValues(eventLevelName(e, level)).Inc()
+}
+
+func (h *PrometheusHook) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{h.lines}
+}
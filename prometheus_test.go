@@ -0,0 +1,38 @@
+//go:build prometheus
+
+// prometheus_test.go
+
+package logger
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+)
+
+func TestPrometheusHook(t *testing.T) {
+	hook := NewPrometheusHook("test")
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(hook.Collectors()...)
+	initTest(t, Config{LogLevel: "Info", LogFilePath: tempLogFile(t, "app.log"), Hooks: []zerolog.Hook{hook}})
+
+	Info("fine")
+	Error("broken")
+	Error("broken again")
+	Notice("maintenance window")
+
+	if got := testutil.ToFloat64(hook.lines.WithLabelValues("error")); got != 2 {
+		t.Errorf("error lines = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(hook.lines.WithLabelValues("info")); got != 1 {
+		t.Errorf("info lines = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(hook.lines.WithLabelValues("notice")); got != 1 {
+		t.Errorf("notice lines = %v, want 1", got)
+	}
+	if n, err := testutil.GatherAndCount(registry, "test_log_lines_total"); err != nil || n != 3 {
+		t.Errorf("registry has %d series (%v), want 3", n, err)
+	}
+}
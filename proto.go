@@ -0,0 +1,138 @@
+//go:build proto
+
+// proto.go
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+// ProtoRecord is a log record ready to be mapped onto the ingestion
+// service's LogRecord message.
+type ProtoRecord struct {
+	Level   string
+	Message string
+	Time    time.Time
+	Fields  map[string]interface{}
+}
+
+// ProtoStream is one client stream to the ingestion service. It wraps the
+// generated client stream: Send maps the record into the generated LogRecord
+// and sends it, and Close ends the stream, e.g. with CloseAndRecv.
+type ProtoStream interface {
+	Send(ProtoRecord) error
+	Close() error
+}
+
+// ProtoStreamFunc opens a ProtoStream on conn, typically by calling the
+// generated client's streaming method.
+type ProtoStreamFunc func(ctx context.Context, conn *grpc.ClientConn) (ProtoStream, error)
+
+// SetProtoStream configures how InitLogger opens the stream for
+// ProtoIngestAddress. It must be called before InitLogger.
+func SetProtoStream(open ProtoStreamFunc, opts ...grpc.DialOption) {
+	newProtoWriter = func(address string) (io.WriteCloser, error) {
+		return NewProtoWriter(address, open, opts...)
+	}
+}
+
+// ProtoWriter streams each JSON record to a gRPC ingestion service as a
+// ProtoRecord. When a Send fails the stream is reopened and the record sent
+// once more; the gRPC connection itself reconnects underneath.
+type ProtoWriter struct {
+	conn   *grpc.ClientConn
+	open   ProtoStreamFunc
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	stream ProtoStream
+}
+
+func NewProtoWriter(address string, open ProtoStreamFunc, opts ...grpc.DialOption) (*ProtoWriter, error) {
+	conn, err := grpc.NewClient(address, opts...)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ProtoWriter{conn: conn, open: open, ctx: ctx, cancel: cancel}, nil
+}
+
+func (w *ProtoWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (w *ProtoWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	record, err := protoRecord(level, p)
+	if err != nil {
+		return 0, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if w.stream == nil {
+			if w.stream, err = w.open(w.ctx, w.conn); err != nil {
+				w.stream = nil
+				return 0, err
+			}
+		}
+		if err = w.stream.Send(record); err == nil {
+			return len(p), nil
+		}
+		// The stream is broken; drop it so the next attempt opens another
+		w.stream.Close()
+		w.stream = nil
+		if attempt > 0 {
+			return 0, err
+		}
+	}
+}
+
+func (w *ProtoWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	if w.stream != nil {
+		err = w.stream.Close()
+		w.stream = nil
+	}
+	w.cancel()
+	if cerr := w.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// protoRecord splits a JSON record into its level, message and timestamp,
+// leaving everything else in Fields.
+func protoRecord(level zerolog.Level, p []byte) (ProtoRecord, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(p, &fields); err != nil {
+		return ProtoRecord{}, err
+	}
+
+	_, name := recordLevel(level, fields[zerolog.LevelFieldName])
+	record := ProtoRecord{Level: name}
+	record.Message, _ = fields[zerolog.MessageFieldName].(string)
+	if ts, ok := fields[zerolog.TimestampFieldName].(string); ok {
+		if t, err := time.Parse(zerolog.TimeFieldFormat, ts); err == nil {
+			record.Time = t
+		}
+	}
+	delete(fields, zerolog.LevelFieldName)
+	delete(fields, zerolog.MessageFieldName)
+	delete(fields, zerolog.TimestampFieldName)
+	record.Fields = fields
+	return record, nil
+}
@@ -0,0 +1,175 @@
+//go:build proto
+
+// proto_test.go
+
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ingestDesc stands in for a generated client-streaming service; records
+// travel as structpb.Struct messages.
+var ingestDesc = grpc.StreamDesc{StreamName: "Stream", ClientStreams: true}
+
+const ingestMethod = "/ingest.LogIngest/Stream"
+
+// structStream maps ProtoRecords onto structpb messages, as an adapter over
+// a generated client stream would onto its LogRecord.
+type structStream struct {
+	grpc.ClientStream
+}
+
+func (s structStream) Send(r ProtoRecord) error {
+	msg, err := structpb.NewStruct(map[string]interface{}{
+		"level":   r.Level,
+		"message": r.Message,
+		"time":    r.Time.Format(time.RFC3339Nano),
+		"fields":  r.Fields,
+	})
+	if err != nil {
+		return err
+	}
+	return s.SendMsg(msg)
+}
+
+func (s structStream) Close() error {
+	if err := s.CloseSend(); err != nil {
+		return err
+	}
+	return s.RecvMsg(&emptypb.Empty{})
+}
+
+func openStructStream(ctx context.Context, conn *grpc.ClientConn) (ProtoStream, error) {
+	cs, err := conn.NewStream(ctx, &ingestDesc, ingestMethod)
+	if err != nil {
+		return nil, err
+	}
+	return structStream{cs}, nil
+}
+
+// ingestServer starts an in-memory ingestion service passing every received
+// record to the returned channel. When perStream is positive each stream is
+// ended by the server after that many records.
+func ingestServer(t *testing.T, perStream int) (grpc.DialOption, <-chan *structpb.Struct, *atomic.Int32) {
+	t.Helper()
+	ln := bufconn.Listen(1 << 20)
+	received := make(chan *structpb.Struct, 64)
+	var streams atomic.Int32
+
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "ingest.LogIngest",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "Stream",
+			ClientStreams: true,
+			Handler: func(_ interface{}, stream grpc.ServerStream) error {
+				streams.Add(1)
+				for n := 0; perStream <= 0 || n < perStream; n++ {
+					msg := new(structpb.Struct)
+					if err := stream.RecvMsg(msg); errors.Is(err, io.EOF) {
+						break
+					} else if err != nil {
+						return err
+					}
+					received <- msg
+				}
+				return stream.SendMsg(&emptypb.Empty{})
+			},
+		}},
+	}, nil)
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+
+	dialer := grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return ln.DialContext(ctx)
+	})
+	return dialer, received, &streams
+}
+
+func receiveRecord(t *testing.T, received <-chan *structpb.Struct) map[string]interface{} {
+	t.Helper()
+	select {
+	case msg := <-received:
+		return msg.AsMap()
+	case <-time.After(5 * time.Second):
+		t.Fatal("no record received")
+		return nil
+	}
+}
+
+func TestProtoWriter(t *testing.T) {
+	dialer, received, _ := ingestServer(t, 0)
+	SetProtoStream(openStructStream, dialer, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	t.Cleanup(func() { newProtoWriter = nil })
+
+	initTest(t, Config{LogLevel: "Info", ProtoIngestAddress: "passthrough:///ingest"})
+	Warn("disk nearly full", "free", "12MB", "volume", "/data")
+
+	record := receiveRecord(t, received)
+	if record["level"] != "warn" || record["message"] != "disk nearly full" {
+		t.Fatalf("got %v", record)
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, record["time"].(string)); err != nil || time.Since(ts) > time.Minute {
+		t.Errorf("time = %v (%v)", record["time"], err)
+	}
+	fields, _ := record["fields"].(map[string]interface{})
+	if fields["free"] != "12MB" || fields["volume"] != "/data" {
+		t.Errorf("fields = %v", fields)
+	}
+	for _, key := range []string{"level", "message", "time"} {
+		if _, ok := fields[key]; ok {
+			t.Errorf("%q left in fields: %v", key, fields)
+		}
+	}
+}
+
+func TestProtoWriterReopensStream(t *testing.T) {
+	dialer, received, streams := ingestServer(t, 1)
+	w, err := NewProtoWriter("passthrough:///ingest", openStructStream, dialer, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write([]byte(`{"level":"info","message":"one"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := receiveRecord(t, received)["message"]; got != "one" {
+		t.Fatalf("got %v", got)
+	}
+
+	// The server has ended the first stream; keep writing until a record
+	// arrives over a new one
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if time.Now().After(deadline) {
+			t.Fatal("no record after the stream ended")
+		}
+		w.Write([]byte(`{"level":"info","message":"two"}`))
+		select {
+		case msg := <-received:
+			if got := msg.AsMap()["message"]; got != "two" {
+				t.Fatalf("got %v", got)
+			}
+			if n := streams.Load(); n < 2 {
+				t.Fatalf("%d streams opened, want a new one", n)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// recover.go
+
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// RecoverAndLog logs a recovered panic with its stack trace. It must be
+// deferred directly, as in `defer logger.RecoverAndLog(false)`, for recover to
+// see the panic. With rethrow set the panic continues after it is logged.
+func RecoverAndLog(rethrow bool) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	level := zerolog.ErrorLevel
+	if rethrow {
+		level = zerolog.PanicLevel
+	}
+	logWithFieldsSkip(panicFrames(), level, "recovered from panic", "panic", fmt.Sprint(r), "panic_stack", string(debug.Stack()))
+
+	if rethrow {
+		panic(r)
+	}
+}
+
+// panicFrames counts the runtime frames, such as gopanic and sigpanic,
+// between RecoverAndLog and the function that panicked, so the caller field
+// names the panic site rather than runtime/panic.go.
+func panicFrames() int {
+	pcs := make([]uintptr, 16)
+	// Skip runtime.Callers, panicFrames and RecoverAndLog
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	n := 0
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") || !more {
+			return n
+		}
+		n++
+	}
+}
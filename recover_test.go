@@ -0,0 +1,63 @@
+// recover_test.go
+
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecoverAndLog(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, CallerFuncName: true})
+
+	func() {
+		defer RecoverAndLog(false)
+	}()
+	func() {
+		defer RecoverAndLog(false)
+		var m map[string]int
+		m["key"]++
+	}()
+	rethrown := func() (r interface{}) {
+		defer func() { r = recover() }()
+		func() {
+			defer RecoverAndLog(true)
+			panic("index out of range")
+		}()
+		return nil
+	}()
+	Close()
+
+	if rethrown != "index out of range" {
+		t.Errorf("rethrown panic = %v", rethrown)
+	}
+	var records []map[string]interface{}
+	for _, record := range readRecords(t, path) {
+		if record["message"] == "recovered from panic" {
+			records = append(records, record)
+		}
+	}
+	if len(records) != 2 {
+		t.Fatalf("%d recovered panics logged, want 2", len(records))
+	}
+	for i, want := range []struct{ panic, level string }{
+		{"assignment to entry in nil map", "error"},
+		{"index out of range", "panic"},
+	} {
+		record := records[i]
+		if record["panic"] != want.panic || record["level"] != want.level {
+			t.Errorf("got %v, want panic %q at %s", record, want.panic, want.level)
+		}
+		// The panicking closure, not runtime/panic.go
+		if caller, _ := record["caller"].(string); !strings.Contains(caller, "recover_test.go") {
+			t.Errorf("caller %q isn't the panic site", caller)
+		}
+		if fn, _ := record["func"].(string); !strings.Contains(fn, "TestRecoverAndLog.func") {
+			t.Errorf("func %q isn't the panicking closure", fn)
+		}
+		if stack, _ := record["panic_stack"].(string); !strings.Contains(stack, "TestRecoverAndLog") {
+			t.Errorf("panic_stack %q lacks the test", stack)
+		}
+	}
+}
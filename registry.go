@@ -0,0 +1,24 @@
+// registry.go
+
+package logger
+
+import (
+	"sync"
+)
+
+var registry sync.Map
+
+// Register makes l available to other subsystems under name, replacing any
+// logger previously registered with that name.
+func Register(name string, l *Logger) {
+	registry.Store(name, l)
+}
+
+// Get returns the logger registered under name.
+func Get(name string) (*Logger, bool) {
+	l, ok := registry.Load(name)
+	if !ok {
+		return nil, false
+	}
+	return l.(*Logger), true
+}
@@ -0,0 +1,44 @@
+// registry_test.go
+
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	if _, ok := Get("registry-test-missing"); ok {
+		t.Error("Get found an unregistered name")
+	}
+
+	first, second := With("db", "primary"), With("db", "replica")
+	Register("registry-test-db", first)
+	if got, ok := Get("registry-test-db"); !ok || got != first {
+		t.Errorf("Get = %p, %v, want %p", got, ok, first)
+	}
+	Register("registry-test-db", second)
+	if got, _ := Get("registry-test-db"); got != second {
+		t.Errorf("Get = %p after overwriting, want %p", got, second)
+	}
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("registry-test-%d", i%2)
+			for j := 0; j < 100; j++ {
+				Register(name, With("worker", fmt.Sprint(i)))
+				if l, ok := Get(name); !ok || l == nil {
+					t.Errorf("Get(%q) = %v, %v", name, l, ok)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
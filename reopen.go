@@ -0,0 +1,169 @@
+// reopen.go
+
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	reopenAttempts      = 3
+	reopenCheckInterval = time.Second
+)
+
+// reopenFile backs every file output: LogFilePath, ErrorFilePath,
+// AuditFilePath and ExtraOutputs. It reopens the path when a write
+// fails or, checked at most once per reopenCheckInterval, when the file was
+// deleted or replaced by an external logrotate, so records don't vanish into
+// an unlinked inode.
+//
+// With a buffer size, records are collected and written once size bytes are
+// pending or every defaultFlushInterval. A plain byte slice is used rather
+// than bufio.Writer, whose errors are sticky and would outlive a reopen.
+type reopenFile struct {
+	path string
+	size int
+
+	mu        sync.Mutex
+	file      *os.File
+	closed    bool
+	lastCheck time.Time
+	buf       []byte
+
+	done chan struct{}
+	stop sync.Once
+	wg   sync.WaitGroup
+}
+
+func newReopenFile(path string, bufferSize int) (*reopenFile, error) {
+	file, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &reopenFile{path: path, size: bufferSize, file: file, lastCheck: time.Now(), done: make(chan struct{})}
+	if bufferSize > 0 {
+		r.wg.Add(1)
+		go r.run()
+	}
+	return r, nil
+}
+
+func (r *reopenFile) run() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(defaultFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.mu.Lock()
+			r.flushLocked()
+			r.mu.Unlock()
+		case <-r.done:
+			return
+		}
+	}
+}
+
+func (r *reopenFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		// Not reopened: the path would stay open after Close
+		return 0, os.ErrClosed
+	}
+
+	if r.size <= 0 {
+		return r.write(p)
+	}
+	r.buf = append(r.buf, p...)
+	if len(r.buf) >= r.size {
+		return len(p), r.flushLocked()
+	}
+	return len(p), nil
+}
+
+// flushLocked writes the buffered records; on failure they are dropped so a
+// full disk can't grow the buffer unbounded.
+func (r *reopenFile) flushLocked() error {
+	if len(r.buf) == 0 {
+		return nil
+	}
+	_, err := r.write(r.buf)
+	r.buf = r.buf[:0]
+	return err
+}
+
+func (r *reopenFile) write(p []byte) (int, error) {
+	if now := time.Now(); now.Sub(r.lastCheck) >= reopenCheckInterval {
+		r.lastCheck = now
+		if r.replaced() {
+			r.reopen()
+		}
+	}
+
+	n, err := r.file.Write(p)
+	for i := 0; err != nil && i < reopenAttempts; i++ {
+		if r.reopen() == nil {
+			n, err = r.file.Write(p)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logger: writing %s failed after %d reopen attempts: %v\n", r.path, reopenAttempts, err)
+	}
+	return n, err
+}
+
+// replaced reports whether the path no longer names the open file.
+func (r *reopenFile) replaced() bool {
+	onDisk, err := os.Stat(r.path)
+	if err != nil {
+		return true
+	}
+	open, err := r.file.Stat()
+	return err != nil || !os.SameFile(onDisk, open)
+}
+
+func (r *reopenFile) reopen() error {
+	file, err := openLogFile(r.path)
+	if err != nil {
+		return err
+	}
+	r.file.Close()
+	r.file = file
+	return nil
+}
+
+func (r *reopenFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	err := r.flushLocked()
+	if serr := r.file.Sync(); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// Close flushes and closes the file. Later calls do nothing, so Reinit after
+// the caller's own Close doesn't report an already-closed file.
+func (r *reopenFile) Close() error {
+	var err error
+	r.stop.Do(func() {
+		close(r.done)
+		r.wg.Wait()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		err = r.flushLocked()
+		if cerr := r.file.Close(); err == nil {
+			err = cerr
+		}
+		r.closed = true
+	})
+	return err
+}
@@ -0,0 +1,114 @@
+// reopen_test.go
+
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// expireChecks makes the next write to each file look for a replaced path.
+func expireChecks() {
+	for _, file := range append([]*reopenFile{logFile, errorFile, auditFile}, extraFiles...) {
+		if file != nil {
+			file.mu.Lock()
+			file.lastCheck = time.Time{}
+			file.mu.Unlock()
+		}
+	}
+}
+
+func TestFilesReopenAfterRemoval(t *testing.T) {
+	logPath := tempLogFile(t, "app.log")
+	errorPath := tempLogFile(t, "errors.log")
+	extraPath := tempLogFile(t, "extra.log")
+	initTest(t, Config{
+		LogLevel:      "Info",
+		LogFilePath:   logPath,
+		ErrorFilePath: errorPath,
+		ExtraOutputs:  []OutputSpec{{Path: extraPath}},
+	})
+
+	Error("before rotation")
+	// As logrotate does with the default create mode
+	for _, path := range []string{logPath, errorPath, extraPath} {
+		if err := os.Rename(path, path+".1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expireChecks()
+	Error("after rotation")
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{logPath, errorPath, extraPath} {
+		if got := messages(readRecords(t, path+".1")); !reflect.DeepEqual(got, []string{"before rotation"}) {
+			t.Errorf("%s.1 holds %v", path, got)
+		}
+		if got := messages(readRecords(t, path)); !reflect.DeepEqual(got, []string{"after rotation"}) {
+			t.Errorf("%s holds %v", path, got)
+		}
+	}
+}
+
+func TestReopenFileAfterDelete(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	r, err := newReopenFile(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	r.Write([]byte(`{"message":"one"}` + "\n"))
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	r.lastCheck = time.Time{}
+	r.Write([]byte(`{"message":"two"}` + "\n"))
+
+	if got := messages(readRecords(t, path)); !reflect.DeepEqual(got, []string{"two"}) {
+		t.Fatalf("recreated file holds %v", got)
+	}
+}
+
+func TestReopenFileBufferFlushedOnClose(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	r, err := newReopenFile(path, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Write([]byte(`{"message":"buffered"}` + "\n"))
+	if info, _ := os.Stat(path); info.Size() != 0 {
+		t.Fatal("record written before the buffer filled")
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(readRecords(t, path)); !reflect.DeepEqual(got, []string{"buffered"}) {
+		t.Fatalf("file holds %v after Close", got)
+	}
+}
+
+func BenchmarkFileWrite(b *testing.B) {
+	record := []byte(`{"level":"info","service":"bench","message":"hello"}` + "\n")
+	for _, bench := range []struct {
+		name string
+		size int
+	}{{"unbuffered", 0}, {"buffered64k", 64 << 10}} {
+		b.Run(bench.name, func(b *testing.B) {
+			r, err := newReopenFile(filepath.Join(b.TempDir(), "bench.log"), bench.size)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer r.Close()
+			b.SetBytes(int64(len(record)))
+			for i := 0; i < b.N; i++ {
+				r.Write(record)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// replay.go
+
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const maxReplayLine = 1 << 20
+
+// ReplayFilter selects the records ReplayFileFiltered writes. Zero fields
+// don't filter.
+type ReplayFilter struct {
+	MinLevel string    // e.g. "warn" keeps Warn and more severe records
+	Since    time.Time // keeps records at or after Since
+	Until    time.Time // keeps records before Until
+}
+
+// ReplayFile writes every record of an NDJSON log file to w, e.g. a
+// LogstashWriter for re-ingesting a downloaded file, and returns how many it
+// wrote.
+func ReplayFile(path string, w io.Writer) (int, error) {
+	return ReplayFileFiltered(path, w, ReplayFilter{})
+}
+
+// ReplayFileFiltered is ReplayFile for the records matching filter. Lines
+// that aren't JSON records are skipped.
+func ReplayFileFiltered(path string, w io.Writer, filter ReplayFilter) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	minLevel := zerolog.TraceLevel
+	if filter.MinLevel != "" {
+		minLevel = parseLogLevel(filter.MinLevel)
+	}
+	out := toLevelWriter(w)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxReplayLine)
+	count := 0
+	for scanner.Scan() {
+		var record struct {
+			Level string `json:"level"`
+			Time  string `json:"time"`
+		}
+		line := scanner.Bytes()
+		if json.Unmarshal(line, &record) != nil {
+			continue
+		}
+		if !filter.Since.IsZero() || !filter.Until.IsZero() {
+			t, err := time.Parse(time.RFC3339Nano, record.Time)
+			if err != nil || t.Before(filter.Since) || (!filter.Until.IsZero() && !t.Before(filter.Until)) {
+				continue
+			}
+		}
+
+		level := zerolog.NoLevel
+		if record.Level != "" {
+			level = parseLogLevel(record.Level)
+		}
+		if level < minLevel {
+			continue
+		}
+		if _, err := out.WriteLevel(level, append(line, '\n')); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, scanner.Err()
+}
@@ -0,0 +1,42 @@
+// replay_test.go
+
+package logger
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestReplayFile(t *testing.T) {
+	at := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	for _, tt := range []struct {
+		name   string
+		filter ReplayFilter
+		want   int
+	}{
+		{"all", ReplayFilter{}, 5},
+		{"warn", ReplayFilter{MinLevel: "warn"}, 2},
+		{"since", ReplayFilter{Since: at("2024-06-01T10:00:02Z")}, 3},
+		{"range", ReplayFilter{Since: at("2024-06-01T10:00:01Z"), Until: at("2024-06-01T10:05:00Z")}, 2},
+		{"info in range", ReplayFilter{MinLevel: "info", Until: at("2024-06-01T10:05:00Z")}, 2},
+	} {
+		var buf bytes.Buffer
+		n, err := ReplayFileFiltered("testdata/replay.ndjson", &buf, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if n != tt.want {
+			t.Errorf("%s: replayed %d records, want %d", tt.name, n, tt.want)
+		}
+		if lines := bytes.Count(buf.Bytes(), []byte("\n")); lines != n {
+			t.Errorf("%s: wrote %d lines for %d records", tt.name, lines, n)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// resources.go
+
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// cgroupRoot is where the cgroup hierarchy is mounted.
+var cgroupRoot = "/sys/fs/cgroup"
+
+// cgroupV1Unlimited is the smallest memory.limit_in_bytes cgroup v1 reports
+// for "no limit"; the exact value is page-aligned max int64.
+const cgroupV1Unlimited = 1 << 62
+
+// withResourceLimits adds "mem_limit_bytes" and "cpu_limit" (cores) from the
+// container's cgroup, v2 first then v1. A limit that is unset or unreadable,
+// e.g. outside a container, is left out.
+func withResourceLimits(ctx zerolog.Context) zerolog.Context {
+	if limit, ok := memoryLimit(); ok {
+		ctx = ctx.Int64("mem_limit_bytes", limit)
+	}
+	if limit, ok := cpuLimit(); ok {
+		ctx = ctx.Float64("cpu_limit", limit)
+	}
+	return ctx
+}
+
+func memoryLimit() (int64, bool) {
+	if s, ok := readCgroupFile("memory.max"); ok {
+		limit, err := strconv.ParseInt(s, 10, 64)
+		// "max" fails to parse and means no limit
+		return limit, err == nil
+	}
+	if s, ok := readCgroupFile("memory", "memory.limit_in_bytes"); ok {
+		limit, err := strconv.ParseInt(s, 10, 64)
+		return limit, err == nil && limit < cgroupV1Unlimited
+	}
+	return 0, false
+}
+
+func cpuLimit() (float64, bool) {
+	// cgroup v2: "<quota> <period>" or "max <period>"
+	if s, ok := readCgroupFile("cpu.max"); ok {
+		parts := strings.Fields(s)
+		if len(parts) != 2 {
+			return 0, false
+		}
+		return cpuQuota(parts[0], parts[1])
+	}
+	for _, dir := range []string{"cpu", "cpu,cpuacct"} {
+		quota, ok := readCgroupFile(dir, "cpu.cfs_quota_us")
+		if !ok {
+			continue
+		}
+		period, ok := readCgroupFile(dir, "cpu.cfs_period_us")
+		if !ok {
+			return 0, false
+		}
+		return cpuQuota(quota, period)
+	}
+	return 0, false
+}
+
+// cpuQuota divides quota by period; a quota of "max" or -1 means no limit.
+func cpuQuota(quota, period string) (float64, bool) {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil || q <= 0 {
+		return 0, false
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0, false
+	}
+	return q / p, true
+}
+
+func readCgroupFile(elem ...string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(append([]string{cgroupRoot}, elem...)...))
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(data)), true
+}
@@ -0,0 +1,65 @@
+// resources_test.go
+
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeCgroup points cgroupRoot at a temporary directory holding files, keyed
+// by their path below the root.
+func fakeCgroup(t *testing.T, files map[string]string) {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saved := cgroupRoot
+	cgroupRoot = root
+	t.Cleanup(func() { cgroupRoot = saved })
+}
+
+func TestIncludeResourceLimits(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		files map[string]string
+		mem   interface{}
+		cpu   interface{}
+	}{
+		{"v2", map[string]string{"memory.max": "536870912", "cpu.max": "150000 100000"}, 536870912.0, 1.5},
+		{"v2 unlimited", map[string]string{"memory.max": "max", "cpu.max": "max 100000"}, nil, nil},
+		{"v1", map[string]string{
+			"memory/memory.limit_in_bytes":  "268435456",
+			"cpu,cpuacct/cpu.cfs_quota_us":  "50000",
+			"cpu,cpuacct/cpu.cfs_period_us": "100000",
+		}, 268435456.0, 0.5},
+		{"v1 unlimited", map[string]string{
+			"memory/memory.limit_in_bytes": "9223372036854771712",
+			"cpu/cpu.cfs_quota_us":         "-1",
+			"cpu/cpu.cfs_period_us":        "100000",
+		}, nil, nil},
+		{"no cgroup", nil, nil, nil},
+	} {
+		fakeCgroup(t, tt.files)
+		path := tempLogFile(t, "app.log")
+		initTest(t, Config{LogLevel: "Info", LogFilePath: path, IncludeResourceLimits: true})
+		Info("started")
+		Close()
+
+		record := findRecord(t, path, "started")
+		if got := record["mem_limit_bytes"]; got != tt.mem {
+			t.Errorf("%s: mem_limit_bytes = %v, want %v", tt.name, got, tt.mem)
+		}
+		if got := record["cpu_limit"]; got != tt.cpu {
+			t.Errorf("%s: cpu_limit = %v, want %v", tt.name, got, tt.cpu)
+		}
+	}
+}
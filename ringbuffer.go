@@ -0,0 +1,58 @@
+// ringbuffer.go
+
+package logger
+
+import (
+	"strings"
+	"sync"
+)
+
+var ringBuffer *RingBufferWriter
+
+// RingBufferWriter keeps the most recent records in memory, e.g. for a debug
+// endpoint. Older records are overwritten.
+type RingBufferWriter struct {
+	mu      sync.Mutex
+	records []string
+	next    int
+	full    bool
+}
+
+func NewRingBufferWriter(size int) *RingBufferWriter {
+	return &RingBufferWriter{records: make([]string, size)}
+}
+
+func (r *RingBufferWriter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.records) == 0 {
+		return len(p), nil
+	}
+	r.records[r.next] = strings.TrimSuffix(string(p), "\n")
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+	return len(p), nil
+}
+
+// Recent returns the held records, oldest first.
+func (r *RingBufferWriter) Recent() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]string(nil), r.records[:r.next]...)
+	}
+	return append(append([]string(nil), r.records[r.next:]...), r.records[:r.next]...)
+}
+
+// RecentLogs returns the records held for Config.RingBufferSize, oldest
+// first, or nil when it isn't set.
+func RecentLogs() []string {
+	if ringBuffer == nil {
+		return nil
+	}
+	return ringBuffer.Recent()
+}
@@ -0,0 +1,39 @@
+// ringbuffer_test.go
+
+package logger
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestRecentLogs(t *testing.T) {
+	initTest(t, Config{LogLevel: "Info", LogFilePath: tempLogFile(t, "app.log"), RingBufferSize: 3})
+
+	for i := 0; i < 5; i++ {
+		Info(strconv.Itoa(i))
+	}
+
+	var got []string
+	for _, line := range RecentLogs() {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("%v: %q", err, line)
+		}
+		got = append(got, record["message"].(string))
+	}
+	if want := []string{"2", "3", "4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RecentLogs messages %v, want %v", got, want)
+	}
+}
+
+func TestRingBufferWriterPartial(t *testing.T) {
+	r := NewRingBufferWriter(4)
+	r.Write([]byte("a\n"))
+	r.Write([]byte("b\n"))
+	if got, want := r.Recent(), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Recent() = %q, want %q", got, want)
+	}
+}
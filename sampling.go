@@ -0,0 +1,235 @@
+// sampling.go
+
+package logger
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var droppedBySampling atomic.Uint64
+
+// countingSampler wraps a zerolog.Sampler and counts the records it drops.
+type countingSampler struct {
+	sampler zerolog.Sampler
+}
+
+func (s countingSampler) Sample(lvl zerolog.Level) bool {
+	if s.sampler.Sample(lvl) {
+		return true
+	}
+	droppedBySampling.Add(1)
+	return false
+}
+
+// onceBurstPeriod is long enough that a burst is effectively never renewed,
+// while now+period still fits in the int64 nanoseconds BurstSampler uses.
+const onceBurstPeriod = 100 * 365 * 24 * time.Hour
+
+// newBurstSampler lets the first burst records of each level through
+// unsampled, then keeps 1 in rate. With a zero period the burst is only
+// granted once; otherwise it is renewed every period.
+func newBurstSampler(burst uint32, period time.Duration, rate uint32) zerolog.Sampler {
+	if rate == 0 {
+		rate = 1
+	}
+	if period <= 0 {
+		// zerolog's BurstSampler ignores the burst without a period
+		period = onceBurstPeriod
+	}
+	next := func() zerolog.Sampler {
+		return &zerolog.BurstSampler{Burst: burst, Period: period, NextSampler: &zerolog.BasicSampler{N: rate}}
+	}
+	return zerolog.LevelSampler{
+		TraceSampler: next(),
+		DebugSampler: next(),
+		InfoSampler:  next(),
+		WarnSampler:  next(),
+		ErrorSampler: next(),
+	}
+}
+
+const defaultLevelWarmupRate = 10
+
+// levelWarmup and levelWarmupRate are Config.LevelWarmup and
+// Config.LevelWarmupRate; warmupState is set by SetLevel when it lowers the
+// level.
+var (
+	levelWarmup     time.Duration
+	levelWarmupRate uint32 = defaultLevelWarmupRate
+	warmupState     atomic.Pointer[warmup]
+)
+
+type warmup struct {
+	start time.Time
+	from  zerolog.Level
+	count atomic.Uint64
+}
+
+// warmupSampler ramps in the levels a SetLevel call just enabled: it keeps 1
+// in levelWarmupRate of them at first and all of them once levelWarmup has
+// passed, rising linearly in between. Records at or above the old level, and
+// anything warmupSampler keeps, go on to next.
+type warmupSampler struct {
+	next zerolog.Sampler
+}
+
+func (s warmupSampler) Sample(lvl zerolog.Level) bool {
+	if w := warmupState.Load(); w != nil && lvl < w.from {
+		if elapsed := time.Since(w.start); elapsed < levelWarmup {
+			remaining := 1 - float64(elapsed)/float64(levelWarmup)
+			n := uint64(math.Ceil(float64(levelWarmupRate) * remaining))
+			if n > 1 && w.count.Add(1)%n != 1 {
+				droppedBySampling.Add(1)
+				return false
+			}
+		}
+	}
+	if s.next == nil {
+		return true
+	}
+	return s.next.Sample(lvl)
+}
+
+// DroppedBySampling returns how many records sampling has dropped since start.
+func DroppedBySampling() uint64 {
+	return droppedBySampling.Load()
+}
+
+// callSiteCounters holds an *atomic.Uint64 per InfoSampled call site, keyed
+// by program counter.
+var callSiteCounters sync.Map
+
+// InfoSampled logs about 1 in n calls from the same call site at Info level
+// and drops the rest; n <= 1 logs every call.
+func InfoSampled(n int, message string, fields ...interface{}) {
+	if n > 1 {
+		pc, _, _, _ := runtime.Caller(1)
+		c, _ := callSiteCounters.LoadOrStore(pc, new(atomic.Uint64))
+		if c.(*atomic.Uint64).Add(1)%uint64(n) != 1 {
+			droppedBySampling.Add(1)
+			return
+		}
+	}
+	logWithFields(zerolog.InfoLevel, message, fields...)
+}
+
+const defaultSampleByFieldPeriod = time.Second
+
+// fieldSampler gives every distinct value of one field its own budget of
+// limit records per period, so a single chatty value can't starve the others.
+// Values idle for idleAfter are evicted.
+type fieldSampler struct {
+	field     string
+	limit     uint32
+	period    time.Duration
+	idleAfter time.Duration
+
+	mu        sync.Mutex
+	budgets   map[string]*fieldBudget
+	lastSweep time.Time
+}
+
+type fieldBudget struct {
+	windowStart time.Time
+	count       uint32
+}
+
+var valueSampler *fieldSampler
+
+func newFieldSampler(field string, limit uint32, period time.Duration) *fieldSampler {
+	if period <= 0 {
+		period = defaultSampleByFieldPeriod
+	}
+	return &fieldSampler{
+		field:     field,
+		limit:     limit,
+		period:    period,
+		idleAfter: 10 * period,
+		budgets:   make(map[string]*fieldBudget),
+		lastSweep: time.Now(),
+	}
+}
+
+// allow reports whether a record with the given fields is within its value's
+// budget. Records without the field are never sampled.
+func (s *fieldSampler) allow(fields []interface{}) bool {
+	value, ok := fieldValue(fields, s.field)
+	if !ok {
+		return true
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) > s.idleAfter {
+		for v, b := range s.budgets {
+			if now.Sub(b.windowStart) > s.idleAfter {
+				delete(s.budgets, v)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, ok := s.budgets[value]
+	if !ok || now.Sub(b.windowStart) >= s.period {
+		s.budgets[value] = &fieldBudget{windowStart: now, count: 1}
+		return true
+	}
+	if b.count >= s.limit {
+		return false
+	}
+	b.count++
+	return true
+}
+
+func fieldValue(fields []interface{}, key string) (string, bool) {
+	for i := 0; i+1 < len(fields); i += 2 {
+		if k, ok := fields[i].(string); ok && k == key {
+			v, ok := fields[i+1].(string)
+			return v, ok
+		}
+	}
+	return "", false
+}
+
+// traceSampler keeps or drops whole traces: the trace_id is hashed with a
+// fixed seed, so every record of a trace gets the same verdict.
+type traceSampler struct {
+	rate float64
+	seed uint64
+}
+
+var traceIDSampler *traceSampler
+
+func (s *traceSampler) allow(fields []interface{}) bool {
+	traceID, ok := fieldValue(fields, "trace_id")
+	if !ok {
+		return true
+	}
+	h := fnv.New64a()
+	var seed [8]byte
+	binary.LittleEndian.PutUint64(seed[:], s.seed)
+	h.Write(seed[:])
+	h.Write([]byte(traceID))
+	return float64(mix64(h.Sum64()))/math.MaxUint64 < s.rate
+}
+
+// mix64 is the splitmix64 finalizer. FNV leaves the high bits of similar
+// short ids close together, which would skew the rate comparison.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
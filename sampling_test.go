@@ -0,0 +1,136 @@
+// sampling_test.go
+
+package logger
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// countMessages returns how many records in path have the given message.
+func countMessages(t *testing.T, path, message string) int {
+	t.Helper()
+	n := 0
+	for _, msg := range messages(readRecords(t, path)) {
+		if msg == message {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDroppedBySampling(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, SampleRate: 10})
+
+	before := DroppedBySampling()
+	for i := 0; i < 1000; i++ {
+		Info("tick")
+	}
+	dropped := DroppedBySampling() - before
+	if dropped < 850 || dropped > 950 {
+		t.Errorf("dropped %d of 1000 at 1 in 10, want about 900", dropped)
+	}
+	Close()
+	if kept := countMessages(t, path, "tick"); uint64(kept)+dropped != 1000 {
+		t.Errorf("kept %d and dropped %d, want 1000 in total", kept, dropped)
+	}
+}
+
+func TestSampleByFieldIndependentValues(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, SampleByField: "path", SampleByFieldLimit: 5, SampleByFieldPeriod: time.Hour})
+
+	for i := 0; i < 20; i++ {
+		Info("request", "path", "/orders")
+		Info("request", "path", "/health")
+	}
+	Info("request", "path", "/users")
+	Close()
+
+	counts := make(map[string]int)
+	for _, record := range readRecords(t, path) {
+		if record["message"] == "request" {
+			p, _ := record["path"].(string)
+			counts[p]++
+		}
+	}
+	want := map[string]int{"/orders": 5, "/health": 5, "/users": 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("kept %v, want %v", counts, want)
+	}
+}
+
+func TestTraceSampling(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, TraceSampleRate: 0.5, TraceSampleSeed: 42})
+
+	// With seed 42 the first trace hashes below the rate and the second above
+	const kept, dropped = "a3ce929d0e0e4736", "4bf92f3577b34da6a3ce929d0e0e4736"
+	for i := 0; i < 10; i++ {
+		Info("span", "trace_id", kept)
+		Info("span", "trace_id", dropped)
+	}
+	Close()
+
+	counts := make(map[string]int)
+	for _, record := range readRecords(t, path) {
+		if record["message"] == "span" {
+			id, _ := record["trace_id"].(string)
+			counts[id]++
+		}
+	}
+	if want := map[string]int{kept: 10}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("kept %v, want %v", counts, want)
+	}
+}
+
+func TestSampleBurst(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, SampleBurst: 5, SampleRate: 10})
+
+	for i := 0; i < 105; i++ {
+		Info("retrying", "attempt", strconv.Itoa(i))
+	}
+	Close()
+
+	var attempts []string
+	for _, record := range readRecords(t, path) {
+		if record["message"] == "retrying" {
+			attempts = append(attempts, record["attempt"].(string))
+		}
+	}
+	if len(attempts) < 5 || !reflect.DeepEqual(attempts[:5], []string{"0", "1", "2", "3", "4"}) {
+		t.Fatalf("kept %v, want the first 5 attempts", attempts)
+	}
+	// 1 in 10 of the remaining 100
+	if got := len(attempts) - 5; got != 10 {
+		t.Errorf("kept %d after the burst, want 10: %v", got, attempts)
+	}
+}
+
+func TestInfoSampled(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+	// Call site counters outlive InitLogger; start from zero on every run
+	callSiteCounters.Range(func(pc, _ interface{}) bool {
+		callSiteCounters.Delete(pc)
+		return true
+	})
+
+	for i := 0; i < 100; i++ {
+		InfoSampled(10, "hot path")
+	}
+	// Another call site has its own counter
+	InfoSampled(10, "cold path")
+	Close()
+
+	if got := countMessages(t, path, "hot path"); got < 9 || got > 11 {
+		t.Errorf("kept %d of 100 at 1 in 10, want about 10", got)
+	}
+	if got := countMessages(t, path, "cold path"); got != 1 {
+		t.Errorf("kept %d of the other call site's first call, want 1", got)
+	}
+}
@@ -0,0 +1,50 @@
+// schema.go
+
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog"
+)
+
+// schemaWriter is a development aid that checks every serialized record for
+// required keys and warns on stderr when one is missing. Records are always
+// passed through unchanged.
+type schemaWriter struct {
+	w    zerolog.LevelWriter
+	keys []string
+}
+
+func newSchemaWriter(w io.Writer, keys []string) schemaWriter {
+	if len(keys) == 0 {
+		keys = []string{"service", zerolog.LevelFieldName, zerolog.TimestampFieldName}
+	}
+	return schemaWriter{w: toLevelWriter(w), keys: keys}
+}
+
+func (s schemaWriter) Write(p []byte) (int, error) {
+	s.check(p)
+	return s.w.Write(p)
+}
+
+func (s schemaWriter) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	s.check(p)
+	return s.w.WriteLevel(l, p)
+}
+
+func (s schemaWriter) check(p []byte) {
+	var record map[string]json.RawMessage
+	if err := json.Unmarshal(p, &record); err != nil {
+		fmt.Fprintf(os.Stderr, "logger: schema validation: record is not valid JSON: %v\n", err)
+		return
+	}
+	for _, key := range s.keys {
+		if _, ok := record[key]; !ok {
+			fmt.Fprintf(os.Stderr, "logger: schema validation: record missing required key %q: %s", key, p)
+		}
+	}
+}
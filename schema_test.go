@@ -0,0 +1,31 @@
+// schema_test.go
+
+package logger
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	stderr := swapPipe(t, &os.Stderr)
+	initTest(t, Config{
+		LogLevel:           "Info",
+		LogFilePath:        tempLogFile(t, "app.log"),
+		ValidateSchema:     true,
+		SchemaRequiredKeys: []string{"request_id"},
+	})
+
+	Info("tagged", "request_id", "r1")
+	Info("untagged")
+	Close()
+
+	got := stderr()
+	if !strings.Contains(got, `missing required key "request_id"`) || !strings.Contains(got, `"message":"untagged"`) {
+		t.Errorf("stderr got %q, want a warning for the untagged record", got)
+	}
+	if strings.Contains(got, `"message":"tagged"`) {
+		t.Errorf("stderr got %q, want no warning for the tagged record", got)
+	}
+}
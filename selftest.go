@@ -0,0 +1,53 @@
+// selftest.go
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// sink is a configured output as SelfTest sees it: the bare writer, without
+// the batching, async queue or stdout fallback that would hide its errors.
+type sink struct {
+	name string
+	w    io.Writer
+}
+
+var sinks []sink
+
+func addSink(name string, w io.Writer) {
+	sinks = append(sinks, sink{name: name, w: w})
+}
+
+// SelfTest writes a record marked "self_test": true straight to every
+// configured output, e.g. before reporting readiness, and returns an error
+// naming each output that failed. A network output passes when the write
+// succeeds. The audit file is left out.
+func SelfTest() error {
+	var buf bytes.Buffer
+	zl := zerolog.New(&buf)
+	zl.Log().
+		Str(zerolog.LevelFieldName, zerolog.InfoLevel.String()).
+		Time(zerolog.TimestampFieldName, time.Now().UTC()).
+		Bool("self_test", true).
+		Msg("logger self-test")
+
+	var errs []error
+	for _, s := range sinks {
+		_, err := s.w.Write(buf.Bytes())
+		if f, ok := s.w.(fileOutput); ok && err == nil {
+			// A buffered file only reports write errors once flushed
+			err = f.Sync()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,34 @@
+// selftest_test.go
+
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	if err := SelfTest(); err != nil {
+		t.Fatalf("SelfTest with a writable file: %v", err)
+	}
+	if record := findRecord(t, path, "logger self-test"); record["self_test"] != true {
+		t.Errorf("got %v", record)
+	}
+
+	working := &recordingWriter{}
+	addSink("buffer", working)
+	addSink("broken", failingWriter{})
+	err := SelfTest()
+	if err == nil || !strings.Contains(err.Error(), "broken: sink down") {
+		t.Fatalf("got %v, want an error naming the broken output", err)
+	}
+	if strings.Contains(err.Error(), "buffer") || strings.Contains(err.Error(), "log file") {
+		t.Errorf("%v names a working output", err)
+	}
+	if !strings.Contains(working.String(), `"self_test":true`) {
+		t.Errorf("working output got %q", working.String())
+	}
+}
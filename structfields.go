@@ -0,0 +1,68 @@
+// structfields.go
+
+package logger
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// anyValue carries a value emit writes with event.Interface. It is only
+// produced internally, so the public helpers keep their string-only rule.
+type anyValue struct {
+	v interface{}
+}
+
+// InfoStruct logs the exported fields of v that carry a `log:"name"` tag under
+// their tag names. Fields tagged `log:"-"` are skipped, and tagged fields of a
+// nested struct are attached as "parent.child", one level deep.
+func InfoStruct(message string, v interface{}, fields ...interface{}) {
+	logWithFields(zerolog.InfoLevel, message, append(structFields(v, "", true), fields...)...)
+}
+
+func structFields(v interface{}, prefix string, descend bool) []interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []interface{}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		name, ok := sf.Tag.Lookup("log")
+		if !ok || name == "-" || name == "" || !sf.IsExported() {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if descend && isNestedStruct(fv) {
+			fields = append(fields, structFields(fv.Interface(), prefix+name+".", false)...)
+			continue
+		}
+
+		switch value := fv.Interface().(type) {
+		case string, time.Duration, error:
+			fields = append(fields, prefix+name, value)
+		default:
+			fields = append(fields, prefix+name, anyValue{value})
+		}
+	}
+	return fields
+}
+
+func isNestedStruct(v reflect.Value) bool {
+	t := v.Type()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{})
+}
@@ -0,0 +1,53 @@
+// structfields_test.go
+
+package logger
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testAddress struct {
+	City    string `log:"city"`
+	Street  string
+	Country string `log:"country"`
+}
+
+type testOrder struct {
+	ID       string  `log:"order_id"`
+	Total    float64 `log:"total"`
+	Card     string  `log:"-"`
+	Notes    string
+	Shipping testAddress `log:"shipping"`
+	internal string      `log:"internal"`
+}
+
+func TestInfoStruct(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path, IncludePID: Bool(false), IncludePod: Bool(false)})
+
+	InfoStruct("order placed", testOrder{
+		ID:       "o1",
+		Total:    12.5,
+		Card:     "4111111111111111",
+		Notes:    "leave at door",
+		Shipping: testAddress{City: "Lyon", Street: "1 rue", Country: "FR"},
+		internal: "x",
+	}, "source", "web")
+	Close()
+
+	record := findRecord(t, path, "order placed")
+	for _, key := range []string{"time", "level", "message", "caller", "service"} {
+		delete(record, key)
+	}
+	want := map[string]interface{}{
+		"order_id":         "o1",
+		"total":            12.5,
+		"shipping.city":    "Lyon",
+		"shipping.country": "FR",
+		"source":           "web",
+	}
+	if !reflect.DeepEqual(record, want) {
+		t.Errorf("got %v, want %v", record, want)
+	}
+}
@@ -0,0 +1,44 @@
+// summary.go
+
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// levelCounts counts emitted records per level, indexed by level+1 so
+// TraceLevel (-1) through NoLevel fit.
+var levelCounts [int(zerolog.NoLevel) + 2]atomic.Uint64
+
+// countHook counts each record that passed level filtering and sampling.
+type countHook struct{}
+
+func (countHook) Run(_ *zerolog.Event, level zerolog.Level, _ string) {
+	if i := int(level) + 1; i >= 0 && i < len(levelCounts) {
+		levelCounts[i].Add(1)
+	}
+}
+
+// LogShutdownSummary logs one Info line with the uptime, the records logged
+// per level and the drop counters. Close calls it.
+func LogShutdownSummary() {
+	lines := fieldGroup{}
+	for i := range levelCounts {
+		if n := levelCounts[i].Load(); n > 0 {
+			name := zerolog.Level(i - 1).String()
+			if name == "" {
+				// Custom levels such as notice are logged without a zerolog level
+				name = "other"
+			}
+			lines = append(lines, name, anyValue{n})
+		}
+	}
+	logWithFields(zerolog.InfoLevel, "logger shutdown summary",
+		"uptime", time.Since(startTime),
+		"lines", lines,
+		"dropped_by_sampling", anyValue{DroppedBySampling()},
+		"output_failures", anyValue{OutputFailures()})
+}
@@ -0,0 +1,86 @@
+// summary_test.go
+
+package logger
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestLogShutdownSummary(t *testing.T) {
+	path := tempLogFile(t, "app.log")
+	initTest(t, Config{LogLevel: "Info", LogFilePath: path})
+
+	// The counters live for the process, so compare against their values now
+	count := func(level zerolog.Level) float64 {
+		return float64(levelCounts[int(level)+1].Load())
+	}
+	want := map[string]float64{
+		"info":  count(zerolog.InfoLevel) + 3,
+		"warn":  count(zerolog.WarnLevel) + 2,
+		"error": count(zerolog.ErrorLevel) + 1,
+	}
+	debug := count(zerolog.DebugLevel)
+	dropped := float64(DroppedBySampling())
+
+	for i := 0; i < 3; i++ {
+		Info("request")
+	}
+	Warn("slow request")
+	Warn("slow request")
+	Error("failed")
+	Debug("filtered")
+	LogShutdownSummary()
+	Close()
+
+	summary := findRecord(t, path, "logger shutdown summary")
+	lines, _ := summary["lines"].(map[string]interface{})
+	for level, n := range want {
+		if lines[level] != n {
+			t.Errorf("lines[%q] = %v, want %v", level, lines[level], n)
+		}
+	}
+	if count(zerolog.DebugLevel) != debug {
+		t.Error("filtered Debug record counted")
+	}
+	if summary["dropped_by_sampling"] != dropped {
+		t.Errorf("dropped_by_sampling = %v, want %v", summary["dropped_by_sampling"], dropped)
+	}
+	if uptime, _ := summary["uptime"].(float64); uptime <= 0 {
+		t.Errorf("uptime = %v", summary["uptime"])
+	}
+}
+
+func TestShutdownSummaryAsyncLogstash(t *testing.T) {
+	stderr := swapPipe(t, &os.Stderr)
+	ln, lines := lineListener(t, "tcp")
+	initTest(t, Config{
+		LogLevel:           "Info",
+		LogAnalyserAddress: ln.Addr().String(),
+		LogAnalyserEnabled: true,
+		LogAnalyserAsync:   true,
+	})
+
+	Info("last request")
+	if err := CloseWithTimeout(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	Close()
+
+	want := []string{"last request", "logger shutdown summary"}
+	if got := receiveMessages(t, lines, 2); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	select {
+	case line := <-lines:
+		t.Errorf("got %q after the summary", line)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if out := stderr(); out != "" {
+		t.Errorf("stderr = %q", out)
+	}
+}
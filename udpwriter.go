@@ -0,0 +1,101 @@
+// udpwriter.go
+
+package logger
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+const defaultUDPMaxDatagramSize = 1420
+
+var udpWriter io.WriteCloser
+
+// ErrDatagramTooLarge is returned for a record larger than the datagram size
+// when the UDPWriter has no Chunker.
+var ErrDatagramTooLarge = errors.New("record exceeds the maximum datagram size")
+
+// Chunker splits a record larger than size into datagrams of at most size
+// bytes, framed so the receiver can reassemble them.
+type Chunker func(p []byte, size int) ([][]byte, error)
+
+const (
+	gelfChunkHeaderSize = 12
+	gelfMaxChunks       = 128
+)
+
+// GELFChunker frames chunks per GELF: magic bytes 0x1e 0x0f, an 8-byte
+// message id, the chunk's sequence number and the chunk count, followed by
+// the payload. GELF allows at most 128 chunks.
+func GELFChunker(p []byte, size int) ([][]byte, error) {
+	payload := size - gelfChunkHeaderSize
+	if payload <= 0 {
+		return nil, fmt.Errorf("datagram size %d leaves no room for a GELF chunk", size)
+	}
+	count := (len(p) + payload - 1) / payload
+	if count > gelfMaxChunks {
+		return nil, fmt.Errorf("record needs %d GELF chunks, more than %d", count, gelfMaxChunks)
+	}
+
+	var id [8]byte
+	rand.Read(id[:])
+	chunks := make([][]byte, 0, count)
+	for seq := 0; seq < count; seq++ {
+		end := min((seq+1)*payload, len(p))
+		chunk := make([]byte, 0, gelfChunkHeaderSize+end-seq*payload)
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(seq), byte(count))
+		chunks = append(chunks, append(chunk, p[seq*payload:end]...))
+	}
+	return chunks, nil
+}
+
+// UDPWriter sends each record as a datagram. Records larger than the
+// datagram size, which would otherwise be dropped silently along the path,
+// are split by its Chunker.
+type UDPWriter struct {
+	conn    net.Conn
+	size    int
+	chunker Chunker
+}
+
+// NewUDPWriter dials address over UDP. A size of 0 uses 1420 bytes, which
+// fits a typical MTU; a nil chunker makes oversized writes fail.
+func NewUDPWriter(address string, size int, chunker Chunker) (*UDPWriter, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 {
+		size = defaultUDPMaxDatagramSize
+	}
+	return &UDPWriter{conn: conn, size: size, chunker: chunker}, nil
+}
+
+func (w *UDPWriter) Write(p []byte) (int, error) {
+	if len(p) <= w.size {
+		return w.conn.Write(p)
+	}
+	if w.chunker == nil {
+		return 0, ErrDatagramTooLarge
+	}
+
+	chunks, err := w.chunker(p, w.size)
+	if err != nil {
+		return 0, err
+	}
+	for _, chunk := range chunks {
+		if _, err := w.conn.Write(chunk); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *UDPWriter) Close() error {
+	return w.conn.Close()
+}
@@ -0,0 +1,87 @@
+// udpwriter_test.go
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// udpListener returns a UDP socket on localhost and a func reading the next
+// datagram from it.
+func udpListener(t *testing.T) (net.PacketConn, func() []byte) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, func() []byte {
+		t.Helper()
+		buf := make([]byte, 65536)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf[:n]
+	}
+}
+
+func TestUDPWriterGELFChunks(t *testing.T) {
+	conn, next := udpListener(t)
+	w, err := NewUDPWriter(conn.LocalAddr().String(), 100, GELFChunker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// 88 payload bytes per 100-byte chunk, so 250 bytes take 3 chunks
+	record := bytes.Repeat([]byte("x"), 250)
+	if _, err := w.Write(record); err != nil {
+		t.Fatal(err)
+	}
+	var id []byte
+	var payload []byte
+	for seq := 0; seq < 3; seq++ {
+		chunk := next()
+		if len(chunk) > 100 || chunk[0] != 0x1e || chunk[1] != 0x0f {
+			t.Fatalf("chunk %d: %d bytes, header % x", seq, len(chunk), chunk[:2])
+		}
+		if id == nil {
+			id = chunk[2:10]
+		} else if !bytes.Equal(chunk[2:10], id) {
+			t.Errorf("chunk %d has message id % x, want % x", seq, chunk[2:10], id)
+		}
+		if chunk[10] != byte(seq) || chunk[11] != 3 {
+			t.Errorf("chunk %d numbered %d of %d", seq, chunk[10], chunk[11])
+		}
+		payload = append(payload, chunk[12:]...)
+	}
+	if !bytes.Equal(payload, record) {
+		t.Error("reassembled chunks differ from the record")
+	}
+
+	small := []byte(`{"message":"fits"}`)
+	if _, err := w.Write(small); err != nil {
+		t.Fatal(err)
+	}
+	if got := next(); !bytes.Equal(got, small) {
+		t.Errorf("small record sent as %q", got)
+	}
+}
+
+func TestUDPWriterNoChunker(t *testing.T) {
+	conn, _ := udpListener(t)
+	w, err := NewUDPWriter(conn.LocalAddr().String(), 100, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.Write(bytes.Repeat([]byte("x"), 101)); !errors.Is(err, ErrDatagramTooLarge) {
+		t.Errorf("got %v, want ErrDatagramTooLarge", err)
+	}
+}
@@ -0,0 +1,167 @@
+// writers.go
+
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// multiLevelWriter duplicates each record to all writers. Unlike
+// io.MultiWriter it keeps going when one writer fails, so a broken stdout
+// pipe can't take the file or Logstash outputs down with it. The writer list
+// is copied on change, so writes never wait on AddOutput.
+type multiLevelWriter struct {
+	mu      sync.Mutex
+	writers atomic.Pointer[[]zerolog.LevelWriter]
+}
+
+func newMultiLevelWriter(writers ...io.Writer) *multiLevelWriter {
+	lws := make([]zerolog.LevelWriter, 0, len(writers))
+	for _, w := range writers {
+		lws = append(lws, toLevelWriter(w))
+	}
+	m := &multiLevelWriter{}
+	m.writers.Store(&lws)
+	return m
+}
+
+// add appends w and returns a func removing it again.
+func (m *multiLevelWriter) add(w io.Writer) func() {
+	// A pointer gives the entry an identity to remove it by
+	entry := &struct{ zerolog.LevelWriter }{toLevelWriter(w)}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old := *m.writers.Load()
+	lws := append(old[:len(old):len(old)], entry)
+	m.writers.Store(&lws)
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		old := *m.writers.Load()
+		lws := make([]zerolog.LevelWriter, 0, len(old))
+		for _, lw := range old {
+			if lw != zerolog.LevelWriter(entry) {
+				lws = append(lws, lw)
+			}
+		}
+		m.writers.Store(&lws)
+	}
+}
+
+func toLevelWriter(w io.Writer) zerolog.LevelWriter {
+	if lw, ok := w.(zerolog.LevelWriter); ok {
+		return lw
+	}
+	return zerolog.LevelWriterAdapter{Writer: w}
+}
+
+func (m *multiLevelWriter) Write(p []byte) (int, error) {
+	var errs []error
+	for _, w := range *m.writers.Load() {
+		if _, err := w.Write(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(p), errors.Join(errs...)
+}
+
+func (m *multiLevelWriter) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	var errs []error
+	for _, w := range *m.writers.Load() {
+		if _, err := w.WriteLevel(l, p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(p), errors.Join(errs...)
+}
+
+// levelFilterWriter passes on records at or above level. Unlike
+// zerolog.FilteredLevelWriter, which lets every NoLevel record through, it
+// resolves a custom level such as notice from the record's level field to
+// the level it was registered with.
+type levelFilterWriter struct {
+	w     zerolog.LevelWriter
+	level zerolog.Level
+}
+
+func newLevelFilterWriter(w io.Writer, level zerolog.Level) *levelFilterWriter {
+	return &levelFilterWriter{w: toLevelWriter(w), level: level}
+}
+
+func (f *levelFilterWriter) Write(p []byte) (int, error) {
+	return f.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (f *levelFilterWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	resolved := level
+	if level == zerolog.NoLevel {
+		var record map[string]interface{}
+		if json.Unmarshal(p, &record) == nil {
+			resolved, _ = recordLevel(level, record[zerolog.LevelFieldName])
+		}
+	}
+	if resolved < f.level {
+		return len(p), nil
+	}
+	return f.w.WriteLevel(level, p)
+}
+
+// outputs is the combined writer built by InitLogger, which AddOutput
+// extends.
+var outputs *multiLevelWriter
+
+// AddOutput starts copying every JSON record to w, after any schema or
+// allow-list processing, and returns a func that stops it. It does nothing
+// when the logger was set up with InitWithLogger.
+func AddOutput(w io.Writer) func() {
+	if outputs == nil {
+		return func() {}
+	}
+	return outputs.add(w)
+}
+
+var outputFailures atomic.Uint64
+
+// fallbackWriter sends a record to stdout when the wrapped writer fails, so a
+// broken file or Logstash connection never loses the line outright.
+type fallbackWriter struct {
+	w io.Writer
+}
+
+func (f fallbackWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if err == nil {
+		return n, nil
+	}
+	outputFailures.Add(1)
+	return os.Stdout.Write(p)
+}
+
+func (f fallbackWriter) Flush() error {
+	if fl, ok := f.w.(flusher); ok {
+		return fl.Flush()
+	}
+	return nil
+}
+
+func (f fallbackWriter) Close() error {
+	if c, ok := f.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// OutputFailures returns how many writes to a file or Logstash output failed
+// and were sent to stdout instead.
+func OutputFailures() uint64 {
+	return outputFailures.Load()
+}
@@ -0,0 +1,79 @@
+// writers_test.go
+
+package logger
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMultiLevelWriterSkipsFailingWriter(t *testing.T) {
+	var sink recordingWriter
+	m := newMultiLevelWriter(failingWriter{}, &sink)
+
+	if _, err := m.Write([]byte("line\n")); err == nil {
+		t.Error("the failing writer's error was not reported")
+	}
+	if got := sink.String(); got != "line\n" {
+		t.Errorf("sink got %q", got)
+	}
+}
+
+func TestFallbackWriterStdout(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	prevStdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = prevStdout }()
+
+	before := OutputFailures()
+	if _, err := (fallbackWriter{w: failingWriter{}}).Write([]byte("line\n")); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "line\n" {
+		t.Errorf("stdout got %q", out)
+	}
+	if got := OutputFailures() - before; got != 1 {
+		t.Errorf("OutputFailures went up by %d, want 1", got)
+	}
+}
+
+func TestAddOutput(t *testing.T) {
+	initTest(t, Config{LogLevel: "Info", LogFilePath: tempLogFile(t, "app.log")})
+
+	var sink recordingWriter
+	Info("before")
+	remove := AddOutput(&sink)
+	Info("during")
+	remove()
+	Info("after")
+
+	if got := sink.String(); !strings.Contains(got, `"message":"during"`) || strings.Count(got, "\n") != 1 {
+		t.Errorf("added output got %q, want only the middle record", got)
+	}
+}
+
+func TestWriterRawBytes(t *testing.T) {
+	initTest(t, Config{LogLevel: "Info", LogFilePath: tempLogFile(t, "app.log")})
+	var sink recordingWriter
+	defer AddOutput(&sink)()
+
+	raw := `127.0.0.1 - - [01/Jun/2024:10:00:00 +0000] "GET / HTTP/1.1" 200 512` + "\n"
+	if _, err := io.WriteString(Writer(), raw); err != nil {
+		t.Fatal(err)
+	}
+	if got := sink.String(); got != raw {
+		t.Errorf("output got %q, want the bytes unchanged", got)
+	}
+}